@@ -0,0 +1,75 @@
+// Ancestry-specific generation rules.
+
+package sotdlgen
+
+// ancestryHooks maps ancestries to the special rules applied to them after
+// the ancestry, gender and name have been set.
+var ancestryHooks = map[string]func(c *Character, opts Opts){
+	"Changeling": (*Character).setGuise,
+	"Clockwork":  (*Character).setPurpose,
+}
+
+// Ancestries a changeling may appear to belong to; each has name lists.
+var changelingGuises = []string{"Human", "Dwarf", "Goblin", "Orc"}
+
+// Purpose represents a clockwork's purpose and the attributes, one of which
+// is increased by 2, it favors.
+type Purpose struct {
+	Name       string
+	Attributes []string
+}
+
+var clockworkPurposes = []Purpose{
+	{"Soldier", []string{"Strength", "Agility"}},
+	{"Worker", []string{"Strength", "Will"}},
+	{"Guardian", []string{"Will", "Agility"}},
+	{"Scholar", []string{"Intellect", "Will"}},
+	{"Diplomat", []string{"Will", "Intellect"}},
+	{"Scout", []string{"Agility", "Intellect"}},
+}
+
+var clockworkKeyLocations = []string{
+	"Back", "Chest", "Head", "Left arm", "Right arm", "Stomach",
+}
+
+// applyAncestryRules runs the special rules for the character's ancestry, if
+// any.
+func (c *Character) applyAncestryRules(opts Opts) {
+	if hook, ok := ancestryHooks[c.Ancestry]; ok {
+		log.Info("Applying", c.Ancestry, "ancestry rules.")
+		hook(c, opts)
+	}
+}
+
+// setGuise gives a changeling an apparent ancestry, gender and name. The
+// apparent gender is drawn from the genders asked for, if any.
+func (c *Character) setGuise(opts Opts) {
+	c.ApparentAncestry = randomChoice(changelingGuises)
+	c.ApparentGender = randomChoice(genderChoices(opts.Genders))
+	c.ApparentName = randomName(c.ApparentAncestry, "", c.ApparentGender, false)
+}
+
+// setPurpose rolls a clockwork's purpose and key location and adjusts its
+// attributes accordingly.
+func (c *Character) setPurpose(Opts) {
+	p := clockworkPurposes[randomInt(0, len(clockworkPurposes))]
+	c.Purpose = p.Name
+	c.KeyLocation = randomChoice(clockworkKeyLocations)
+	c.incrAttr(randomChoice(p.Attributes), 2)
+	c.calcDerived()
+	c.calcHealingRate()
+}
+
+// incrAttr increments the named attribute by n.
+func (c *Character) incrAttr(attr string, n int) {
+	switch attr {
+	case "Strength":
+		c.Attributes.Strength += n
+	case "Agility":
+		c.Attributes.Agility += n
+	case "Intellect":
+		c.Attributes.Intellect += n
+	case "Will":
+		c.Attributes.Will += n
+	}
+}
@@ -0,0 +1,57 @@
+package sotdlgen
+
+import "testing"
+
+func TestApplyAncestryRules(t *testing.T) {
+	saved := db
+	defer func() { db = saved }()
+	db = CharDB{Names: []NameList{
+		{Ancestry: "Orc", Ethnicity: "Orc", Type: "Male", Names: []string{"Grom"}},
+		{Ancestry: "Orc", Ethnicity: "Orc", Type: "Female", Names: []string{"Ula"}},
+	}}
+	savedGuises := changelingGuises
+	defer func() { changelingGuises = savedGuises }()
+	changelingGuises = []string{"Orc"}
+
+	setSeed("1575d911f49e59ee")
+
+	c := Character{Ancestry: "Changeling"}
+	c.applyAncestryRules(Opts{Genders: "Female"})
+	if c.ApparentAncestry != "Orc" {
+		t.Errorf("Incorrect apparent ancestry. Expected 'Orc', got '%s'.", c.ApparentAncestry)
+	}
+	if c.ApparentGender != "Female" || c.ApparentName != "Ula" {
+		t.Errorf("Apparent gender '%s' and name '%s' not drawn from the genders asked for.",
+			c.ApparentGender, c.ApparentName)
+	}
+
+	c = Character{Ancestry: "Clockwork"}
+	c.applyAncestryRules(Opts{})
+	if c.Purpose == "" || c.KeyLocation == "" {
+		t.Error("Clockwork is missing its purpose or key location.")
+	}
+	a := c.Attributes
+	if a.Strength+a.Agility+a.Intellect+a.Will != 2 {
+		t.Error("Clockwork purpose did not adjust attributes by 2.")
+	}
+
+	c = Character{Ancestry: "Human"}
+	c.applyAncestryRules(Opts{})
+	if c.ApparentAncestry != "" || c.Purpose != "" {
+		t.Error("Special rules applied to an ordinary ancestry.")
+	}
+}
+
+func TestChangelingGuiseNames(t *testing.T) {
+	saved := db
+	defer func() { db = saved }()
+	db = CharDB{}
+	if err := db.AddNames("./assets/sotdl_names.json"); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range changelingGuises {
+		if len(db.NameLists(a)) == 0 {
+			t.Errorf("No names for the changeling guise %s.", a)
+		}
+	}
+}
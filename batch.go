@@ -0,0 +1,102 @@
+// Batch generation of characters, e.g., for NPC rosters.
+
+package sotdlgen
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// deriveSeed derives the seed of the ith character of a batch from the
+// batch's master seed.
+func deriveSeed(master string, i int) string {
+	h := fnv.New64a()
+	h.Write([]byte(master + "/" + strconv.Itoa(i)))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// newMasterSeed returns a seed for a batch, party or constrained search when
+// none is given.
+func newMasterSeed() string {
+	return fmt.Sprintf("%016x", time.Now().UTC().UnixNano())
+}
+
+// NewBatch generates n characters. Options that are set apply to every
+// character; those left empty are randomized per character. Each character
+// is seeded from opts.Seed, the master seed, so the batch is reproducible,
+// and no two characters share a name.
+func NewBatch(opts Opts, n int) ([]Character, error) {
+	if n < 1 {
+		return nil, ValidationError{{"size", fmt.Sprintf("must be positive, got %d", n)}}
+	}
+	master := opts.Seed
+	if master == "" {
+		master = newMasterSeed()
+	}
+	if opts.UsedNames == nil {
+		opts.UsedNames = NewNameSet()
+	}
+	chars := make([]Character, 0, n)
+	for i := 0; i < n; i++ {
+		o := opts
+		o.Seed = deriveSeed(master, i)
+		c, err := NewCharacter(o)
+		if err != nil {
+			return chars, err
+		}
+		chars = append(chars, c)
+	}
+	return chars, nil
+}
+
+// WriteJSONL writes the characters as JSON Lines, one character per line.
+func WriteJSONL(w io.Writer, chars []Character) error {
+	enc := json.NewEncoder(w)
+	for _, c := range chars {
+		if err := enc.Encode(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvHeader names the columns written by WriteCSV.
+var csvHeader = []string{
+	"name", "gender", "pronouns", "ancestry", "novice_path", "expert_path", "master_path",
+	"level", "strength", "agility", "intellect", "will", "perception", "defense", "health",
+	"healing_rate", "size", "speed", "power", "insanity", "corruption",
+	"apparent_ancestry", "apparent_gender", "apparent_name", "purpose", "key_location",
+	"languages_and_professions", "talents", "seed",
+}
+
+// csvRecord flattens a character into a CSV row matching csvHeader.
+func (c Character) csvRecord() []string {
+	a := c.Attributes
+	itoa := strconv.Itoa
+	return []string{
+		c.Name, c.Gender, c.Pronouns.String(), c.Ancestry, c.NovicePath, c.ExpertPath, c.MasterPath,
+		itoa(c.Level), itoa(a.Strength), itoa(a.Agility), itoa(a.Intellect), itoa(a.Will),
+		itoa(a.Perception), itoa(a.Defense), itoa(a.Health),
+		itoa(a.HealingRate), a.Size, itoa(a.Speed), itoa(a.Power), itoa(a.Insanity), itoa(a.Corruption),
+		c.ApparentAncestry, c.ApparentGender, c.ApparentName, c.Purpose, c.KeyLocation,
+		strings.Join(c.LangAndProf, "; "), strings.Join(c.Talents, "; "), c.Seed,
+	}
+}
+
+// WriteCSV writes the characters as a flat CSV table with a header row and
+// one column per attribute.
+func WriteCSV(w io.Writer, chars []Character) error {
+	cw := csv.NewWriter(w)
+	cw.Write(csvHeader)
+	for _, c := range chars {
+		cw.Write(c.csvRecord())
+	}
+	cw.Flush()
+	return cw.Error()
+}
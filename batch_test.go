@@ -0,0 +1,66 @@
+package sotdlgen
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewBatch(t *testing.T) {
+	useTestDB(t)
+	opts := Opts{Seed: "1575d911f49e59ee", Ancestry: "Orc", LogLevel: "ERROR"}
+	chars, err := NewBatch(opts, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chars) != 20 {
+		t.Fatalf("Expected 20 characters, got %d.", len(chars))
+	}
+	names := map[string]bool{}
+	for _, c := range chars {
+		if c.Ancestry != "Orc" {
+			t.Errorf("Fixed ancestry not applied; got '%s'.", c.Ancestry)
+		}
+		if names[c.Name] {
+			t.Errorf("Duplicate name '%s'.", c.Name)
+		}
+		names[c.Name] = true
+	}
+	again, _ := NewBatch(opts, 20)
+	if !reflect.DeepEqual(chars, again) {
+		t.Error("Batches with the same master seed differ.")
+	}
+	if chars[0].Seed == chars[1].Seed {
+		t.Error("Batch items share a seed.")
+	}
+	if _, err = NewBatch(opts, 0); !isValidationError(err) {
+		t.Errorf("Expected a validation error for an empty batch, got %v.", err)
+	}
+}
+
+func TestWriteBatch(t *testing.T) {
+	chars := []Character{testCharacter, loadFixture(t)}
+	b := &bytes.Buffer{}
+	if err := WriteJSONL(b, chars); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(b.String(), "\n"); n != 2 {
+		t.Errorf("Expected 2 JSON lines, got %d.", n)
+	}
+	b.Reset()
+	if err := WriteCSV(b, chars); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(b).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || len(rows[1]) != len(csvHeader) {
+		t.Fatalf("Incorrect CSV shape: %d rows.", len(rows))
+	}
+	if rows[2][0] != "Vesna Ironjaw" || rows[2][8] != "13" {
+		t.Errorf("Incorrect CSV row: %v.", rows[2])
+	}
+}
@@ -0,0 +1,227 @@
+// Browsing and searching the character db.
+
+package sotdlgen
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// benefits lists the level's attribute and characteristic changes.
+func (lvl *Level) benefits() []string {
+	items := []string{}
+	add := func(name string, n int) {
+		if n != 0 {
+			items = append(items, fmt.Sprintf("%s %s", name, signed(n)))
+		}
+	}
+	add("Strength", lvl.Strength)
+	add("Agility", lvl.Agility)
+	add("Intellect", lvl.Intellect)
+	add("Will", lvl.Will)
+	add("Perception", lvl.PerceptionMod)
+	add("Defense", lvl.DefenseMod)
+	add("Health", lvl.HealthMod)
+	add("Speed", lvl.Speed)
+	add("Power", lvl.Power)
+	add("Damage", lvl.Damage)
+	add("Insanity", lvl.Insanity)
+	add("Corruption", lvl.Corruption)
+	if lvl.HealingRate != 0 {
+		items = append(items, fmt.Sprintf("Healing Rate %g x Health", lvl.HealingRate))
+	}
+	if lvl.Size != "" {
+		items = append(items, "Size "+lvl.Size)
+	}
+	return items
+}
+
+// orList joins items as alternatives, e.g., "A, B or C".
+func orList(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
+
+// levelNumbers returns the levels of the path in ascending order.
+func (lvls Levels) levelNumbers() []int {
+	keys := []int{}
+	for k := range lvls {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// WritePath writes a plain-text description of the ancestry or path, level
+// by level, wrapped to the given width. If level is not negative, only that
+// level is described.
+func (db *CharDB) WritePath(w io.Writer, path string, level, width int) error {
+	if width < minWidth {
+		width = minWidth
+	}
+	name, ok := LookupPath(path)
+	lvls := db.Paths[name]
+	if !ok || lvls == nil {
+		return fmt.Errorf("unknown path '%s'", path)
+	}
+	b := &strings.Builder{}
+	title := fmt.Sprintf("%s (%s)", name, PathTier(name))
+	if PathTier(name) != "ancestry" {
+		title = fmt.Sprintf("%s (%s path)", name, PathTier(name))
+	}
+	fmt.Fprintln(b, title)
+	fmt.Fprintln(b, strings.Repeat("=", len(title)))
+	if pr, ok := db.Prereqs[name]; ok {
+		reqs := []string{}
+		if len(pr.Paths) > 0 {
+			reqs = append(reqs, "the "+orList(pr.Paths)+" path")
+		}
+		if len(pr.Traditions) > 0 {
+			reqs = append(reqs, "the "+orList(pr.Traditions)+" tradition")
+		}
+		fmt.Fprintln(b, wrapText("Requires: "+strings.Join(reqs, "; "), width, 2))
+	}
+	if ts := db.Traditions[name]; len(ts) > 0 {
+		if stringIn(ts, anyTradition) {
+			ts = []string{"any"}
+		}
+		fmt.Fprintln(b, wrapText("Traditions: "+strings.Join(ts, ", "), width, 2))
+	}
+	found := false
+	for _, i := range lvls.levelNumbers() {
+		if level >= 0 && i != level {
+			continue
+		}
+		found = true
+		lvl := lvls[i]
+		fmt.Fprintf(b, "\nLevel %d\n", i)
+		if bs := lvl.benefits(); len(bs) > 0 {
+			fmt.Fprintf(b, "  %s\n", wrapText(strings.Join(bs, ", "), width, 2))
+		}
+		for _, l := range []struct {
+			title string
+			items []string
+		}{{"Languages and Professions", lvl.LangAndProf}, {"Talents", lvl.Talents}} {
+			if len(l.items) > 0 {
+				fmt.Fprintf(b, "  %s\n", l.title)
+				for _, item := range l.items {
+					fmt.Fprintf(b, "    - %s\n", wrapText(item, width, 6))
+				}
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("%s has no level %d; its levels are %v", name, level, lvls.levelNumbers())
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Kinds of rules text that may be searched.
+const (
+	SearchTalents     = "talent"
+	SearchProfessions = "profession"
+)
+
+// SearchResult is a talent or language and profession entry matching a
+// search.
+type SearchResult struct {
+	Path  string `json:"path"`
+	Level int    `json:"level"`
+	Kind  string `json:"kind"`
+	Text  string `json:"text"`
+}
+
+// Search finds the talents or language and profession entries (or both, if
+// kind is empty) containing every word of the query, ignoring case. Results
+// are ordered by path and level.
+func (db *CharDB) Search(kind, query string) ([]SearchResult, error) {
+	if kind != "" && kind != SearchTalents && kind != SearchProfessions {
+		return nil, fmt.Errorf("unknown kind '%s'; expected one of {%s, %s}",
+			kind, SearchTalents, SearchProfessions)
+	}
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty search")
+	}
+	matches := func(text string) bool {
+		text = strings.ToLower(text)
+		for _, w := range words {
+			if !strings.Contains(text, w) {
+				return false
+			}
+		}
+		return true
+	}
+	all, _ := PathsByTier("")
+	results := []SearchResult{}
+	for _, path := range append(Ancestries(), all...) {
+		lvls := db.Paths[path]
+		for _, i := range lvls.levelNumbers() {
+			entries := map[string][]string{
+				SearchTalents:     lvls[i].Talents,
+				SearchProfessions: lvls[i].LangAndProf,
+			}
+			for _, k := range []string{SearchTalents, SearchProfessions} {
+				if kind != "" && kind != k {
+					continue
+				}
+				for _, text := range entries[k] {
+					if matches(text) {
+						results = append(results, SearchResult{path, i, k, text})
+					}
+				}
+			}
+		}
+	}
+	return results, nil
+}
+
+// WriteSearchResults writes search results as plain text, one per entry,
+// wrapped to the given width.
+func WriteSearchResults(w io.Writer, results []SearchResult, width int) error {
+	if width < minWidth {
+		width = minWidth
+	}
+	const labelWidth = 18
+	b := &strings.Builder{}
+	for _, r := range results {
+		label := fmt.Sprintf("%s %d", r.Path, r.Level)
+		fmt.Fprintf(b, "%-*s %s\n", labelWidth, label, wrapText(r.Text, width, labelWidth+1))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// PathInfo summarizes an ancestry or path in the character db.
+type PathInfo struct {
+	Name       string   `json:"name"`
+	Tier       string   `json:"tier"`
+	Levels     []int    `json:"levels"`
+	Prereq     *Prereq  `json:"prereq,omitempty"`
+	Traditions []string `json:"traditions,omitempty"`
+}
+
+// PathInfo returns a summary of the named ancestry or path, ignoring case,
+// or false if the db has no such path.
+func (db *CharDB) PathInfo(name string) (PathInfo, bool) {
+	name, ok := LookupPath(name)
+	lvls, loaded := db.Paths[name]
+	if !ok || !loaded {
+		return PathInfo{}, false
+	}
+	info := PathInfo{
+		Name:       name,
+		Tier:       PathTier(name),
+		Levels:     lvls.levelNumbers(),
+		Traditions: db.Traditions[name],
+	}
+	if pr, ok := db.Prereqs[name]; ok {
+		info.Prereq = &pr
+	}
+	return info, true
+}
@@ -0,0 +1,68 @@
+package sotdlgen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWritePath(t *testing.T) {
+	useTestDB(t)
+	b := &bytes.Buffer{}
+	if err := db.WritePath(b, "warrior", -1, 60); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, s := range []string{"Warrior (novice path)", "Level 1", "Level 8", "Catch Your Breath"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Path description is missing '%s':\n%s", s, out)
+		}
+	}
+	b.Reset()
+	db.WritePath(b, "Mage Knight", 7, 60)
+	if !strings.Contains(b.String(), "Paladin or Spellbinder") || strings.Contains(b.String(), "Level 10") {
+		t.Errorf("Incorrect master path description:\n%s", b.String())
+	}
+	if err := db.WritePath(b, "Warrior", 3, 60); err == nil {
+		t.Error("Expected an error for a level the path lacks.")
+	}
+	if err := db.WritePath(b, "Bogus", -1, 60); err == nil {
+		t.Error("Expected an error for an unknown path.")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	useTestDB(t)
+	results, err := db.Search(SearchTalents, "your BREATH")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 || results[0].Path != "Warrior" || results[0].Level != 1 {
+		t.Errorf("Expected the Warrior's Catch Your Breath, got %v.", results)
+	}
+	if results, _ = db.Search(SearchProfessions, "breath"); len(results) != 0 {
+		t.Errorf("Expected no professions, got %v.", results)
+	}
+	if _, err = db.Search("spell", "breath"); err == nil {
+		t.Error("Expected an error for an unknown kind.")
+	}
+	b := &bytes.Buffer{}
+	WriteSearchResults(b, []SearchResult{{"Warrior", 1, SearchTalents, "Catch Your Breath"}}, 60)
+	if b.String() != "Warrior 1          Catch Your Breath\n" {
+		t.Errorf("Incorrect search results: '%s'.", b.String())
+	}
+}
+
+func TestPathInfo(t *testing.T) {
+	useTestDB(t)
+	info, ok := db.PathInfo("mage knight")
+	if !ok || info.Name != "Mage Knight" || info.Tier != "master" || info.Prereq == nil {
+		t.Errorf("Incorrect path info: %+v.", info)
+	}
+	if len(info.Levels) != 2 || info.Levels[0] != 7 {
+		t.Errorf("Expected levels [7 10], got %v.", info.Levels)
+	}
+	if _, ok = db.PathInfo("Elf"); ok {
+		t.Error("Expected no info for an unknown path.")
+	}
+}
@@ -3,10 +3,11 @@ package sotdlgen
 
 import (
 	"encoding/json"
-	"fmt"
 	"math"
+	"os"
 	"sort"
 	"strconv"
+	"strings"
 
 	logging "github.com/op/go-logging"
 )
@@ -47,7 +48,7 @@ var (
 		"Technomancer", "Templar", "Tenebrist", "Thaumaturge", "Theurge", "Transmuter",
 		"Traveler", "Weapon Master", "Woodwose", "Zealot",
 	}
-	genders   = []string{"Male", "Female"}
+	genders   = []string{"Male", "Female", "Non-binary"}
 	languages = []string{
 		"Common Tongue", "Dark Speech", "Dwarfish", "Elvish", "High Archaic", "Trollish",
 		"Secret Language",
@@ -58,6 +59,7 @@ var (
 type Character struct {
 	Name        string     `json:"name"`
 	Gender      string     `json:"gender"`
+	Pronouns    Pronouns   `json:"pronouns"`
 	Ancestry    string     `json:"ancestry"`
 	LangAndProf []string   `json:"languages_and_professions"`
 	NovicePath  string     `json:"novice_path"`
@@ -67,12 +69,22 @@ type Character struct {
 	Level       int        `json:"level"`
 	Attributes  Attributes `json:"attributes"`
 	Seed        string     `json:"seed"`
+	// Changeling guise.
+	ApparentAncestry string `json:"apparent_ancestry,omitempty"`
+	ApparentGender   string `json:"apparent_gender,omitempty"`
+	ApparentName     string `json:"apparent_name,omitempty"`
+	// Clockwork purpose.
+	Purpose     string `json:"purpose,omitempty"`
+	KeyLocation string `json:"key_location,omitempty"`
 	//Background  string     `json:"background"`
 	//Description string     `json:"description"`
-	//Magic       []Spell    `json:"magic"`
-	//Weapons     []Weapon   `json:"weapons"`
-	//Armor       []Armor    `json:"armor"`
-	//Equipment   []string   `json:"equipment"`
+	Magic     []Spell  `json:"magic,omitempty"`
+	Weapons   []Weapon `json:"weapons,omitempty"`
+	Armor     []Armor  `json:"armor,omitempty"`
+	Equipment []string `json:"equipment,omitempty"`
+	// chooseAttr, if set, picks each attribute to increase; "" picks one at
+	// random.
+	chooseAttr func() string
 }
 
 // Attributes represents character statistics.
@@ -139,9 +151,16 @@ type Armor struct {
 	DefenseBonus int    `json:"defense"`
 }
 
-// Randomly increments n character attributes by 1.
+// Randomly increments n character attributes by 1, unless the character
+// has a chooser for them.
 func (c *Character) incrRandomAttrs(n int) {
 	for i := 0; i < n; i++ {
+		if c.chooseAttr != nil {
+			if attr := c.chooseAttr(); attr != "" {
+				c.incrAttr(attr, 1)
+				continue
+			}
+		}
 		switch randomInt(0, 4) {
 		case 0:
 			c.Attributes.Strength++
@@ -164,13 +183,13 @@ func (c *Character) setCharSeed(charSeed string) {
 	}
 }
 
-// Pick a random level in [0..10] if non supplied.
-func (c *Character) setLevel(level string) {
-	if level != "" {
-		c.Level, _ = strconv.Atoi(level)
-	} else {
-		c.Level = randomInt(0, 10)
+// parseLevel returns the given level, or a random one if it is empty.
+func parseLevel(level string) int {
+	if level == "" {
+		return randomInt(0, 10)
 	}
+	l, _ := strconv.Atoi(level)
+	return l
 }
 
 func (c *Character) calcHealingRate() {
@@ -216,7 +235,10 @@ func (c *Character) setPath(path string) {
 		c.ExpertPath = path
 	} else if c.MasterPath == "" {
 		if path == "" {
-			path = randomChoice(masterPaths)
+			path = randomChoice(c.eligibleMasterPaths())
+		} else if !c.qualifiesFor(path) {
+			log.Warningf("%s %s does not meet the prerequisites for %s; using it anyway.",
+				c.NovicePath, c.ExpertPath, path)
 		}
 		c.MasterPath = path
 	} else {
@@ -229,42 +251,8 @@ func (c *Character) setPath(path string) {
 	}
 	sort.Ints(keys)
 	for _, i := range keys {
-		lvl := db.Paths[path][i]
 		if i <= c.Level {
-			// Attributes
-			c.Attributes.Strength += lvl.Strength
-			c.Attributes.Agility += lvl.Agility
-			c.Attributes.Intellect += lvl.Intellect
-			c.Attributes.Will += lvl.Will
-
-			// Characteristics
-			c.Attributes.perceptionMod += lvl.PerceptionMod
-			c.Attributes.defenseMod += lvl.DefenseMod
-			c.Attributes.healthMod += lvl.HealthMod
-
-			c.Attributes.Speed += lvl.Speed
-			c.Attributes.Power += lvl.Power
-
-			if lvl.Size != "" {
-				c.Attributes.Size = lvl.Size
-			}
-
-			c.Attributes.Insanity += lvl.Insanity
-			c.Attributes.Corruption += lvl.Corruption
-
-			if lvl.HealingRate != 0.0 {
-				c.Attributes.healingRateMultiplier = lvl.HealingRate
-			}
-
-			// Talents
-			c.Talents = append(c.Talents, lvl.Talents...)
-
-			// Languages and Professions
-			if i == 0 {
-				c.LangAndProf = append(c.LangAndProf,
-					"Two professions of your choice; you may trade one for a language.")
-			}
-			c.LangAndProf = append(c.LangAndProf, lvl.LangAndProf...)
+			c.applyLevel(path, i)
 		}
 		// Attribute increases
 		c.increaseAttributes(i, path)
@@ -274,53 +262,261 @@ func (c *Character) setPath(path string) {
 	c.calcHealingRate()
 }
 
-// Randomly sample from name db.
-func (c *Character) setName(name string) {
+// applyLevel adds the benefits of the ith level of the path.
+func (c *Character) applyLevel(path string, i int) {
+	lvl, ok := db.Paths[path][i]
+	if !ok {
+		return
+	}
+	// Attributes
+	c.Attributes.Strength += lvl.Strength
+	c.Attributes.Agility += lvl.Agility
+	c.Attributes.Intellect += lvl.Intellect
+	c.Attributes.Will += lvl.Will
+
+	// Characteristics
+	c.Attributes.perceptionMod += lvl.PerceptionMod
+	c.Attributes.defenseMod += lvl.DefenseMod
+	c.Attributes.healthMod += lvl.HealthMod
+
+	c.Attributes.Speed += lvl.Speed
+	c.Attributes.Power += lvl.Power
+
+	if lvl.Size != "" {
+		c.Attributes.Size = lvl.Size
+	}
+
+	c.Attributes.Insanity += lvl.Insanity
+	c.Attributes.Corruption += lvl.Corruption
+
+	if lvl.HealingRate != 0.0 {
+		c.Attributes.healingRateMultiplier = lvl.HealingRate
+	}
+
+	// Talents
+	c.Talents = append(c.Talents, lvl.Talents...)
+
+	// Languages and Professions
+	if i == 0 {
+		c.LangAndProf = append(c.LangAndProf, professionsPlaceholder)
+	}
+	c.LangAndProf = append(c.LangAndProf, lvl.LangAndProf...)
+}
+
+// Entry in the languages and professions of a new character, replaced once
+// the professions have been chosen.
+const professionsPlaceholder = "Two professions of your choice; you may trade one for a language."
+
+// setProfessions replaces the placeholder professions with the given
+// comma-delimited professions, if any.
+func (c *Character) setProfessions(professions string) {
+	chosen := splitList(professions)
+	if len(chosen) == 0 {
+		return
+	}
+	for i, lp := range c.LangAndProf {
+		if lp == professionsPlaceholder {
+			c.LangAndProf[i] = "Professions: " + strings.Join(chosen, ", ") + "."
+			return
+		}
+	}
+	c.LangAndProf = append(c.LangAndProf, "Professions: "+strings.Join(chosen, ", ")+".")
+}
+
+// Ancestry whose names are used for ancestries without any.
+const defaultNameAncestry = "Human"
+
+// Name generation modes.
+const (
+	NameGenList   = "list"
+	NameGenMarkov = "markov"
+)
+
+// Randomly sample from name db, or synthesize a name from it, avoiding any
+// names already used.
+func (c *Character) setName(name, ethnicity, nameGen string, used *NameSet) {
 	if name != "" {
 		c.Name = name
-	} else {
-		firstNames := []string{}
-		surnames := []string{}
-		ethnicities := []string{}
-		for _, nl := range db.Names {
-			if nl.Ancestry == c.Ancestry && !arrayContains(ethnicities, nl.Ethnicity) {
-				ethnicities = append(ethnicities, nl.Ethnicity)
-			}
+		if used != nil {
+			used.Add(name)
 		}
-		ethnicity := ""
-		if len(ethnicities) > 0 {
-			ethnicity = randomChoice(ethnicities)
-		} else {
-			ethnicity = db.Names[randomInt(0, len(db.Names))].Ethnicity
+		return
+	}
+	synth := nameGen == NameGenMarkov
+	for i := 0; i < maxNameRetries; i++ {
+		c.Name = randomName(c.Ancestry, ethnicity, c.Gender, synth)
+		if used == nil || used.Add(c.Name) {
+			return
 		}
-		for _, nl := range db.Names {
-			if nl.Ethnicity == ethnicity {
-				switch nl.Type {
-				case c.Gender:
-					firstNames = append(firstNames, nl.Names...)
-				case "Surname":
-					surnames = append(surnames, nl.Names...)
-				}
-			}
+		// Fall back to synthesis once the lists are exhausted.
+		synth = synth || i >= maxNameRetries/2
+	}
+	// Disambiguate a name that could not be made unique.
+	base := c.Name
+	for n := 2; !used.Add(c.Name); n++ {
+		c.Name = base + " " + strconv.Itoa(n)
+	}
+}
+
+// nameLists returns the name lists to draw names from: the SotDL lists, and
+// secondary lists only for wanted ethnicities that the SotDL lists lack.
+func nameLists(wanted []string) []NameList {
+	primary := map[string]bool{}
+	for _, nl := range db.Names {
+		if !nl.secondary {
+			primary[nl.Ethnicity] = true
 		}
-		firstName := ""
-		surname := ""
-		if len(firstNames) > 0 {
-			firstName = randomChoice(firstNames)
+	}
+	lists := []NameList{}
+	for _, nl := range db.Names {
+		if !nl.secondary || (stringIn(wanted, nl.Ethnicity) && !primary[nl.Ethnicity]) {
+			lists = append(lists, nl)
 		}
-		if len(surnames) > 0 {
-			surname = randomChoice(surnames)
+	}
+	return lists
+}
+
+// randomName samples a full name for the given ancestry and gender from the
+// name db, or synthesizes one in the style of its lists if synth is set. The
+// ethnicity, if given, is a comma-delimited list of ethnicities to draw from
+// instead of those of the ancestry.
+func randomName(ancestry, ethnicity, gender string, synth bool) string {
+	ethnicities := []string{}
+	wanted := splitList(ethnicity)
+	lists := nameLists(wanted)
+	for _, nl := range lists {
+		ok := nl.Ancestry == ancestry
+		if len(wanted) > 0 {
+			ok = stringIn(wanted, nl.Ethnicity)
+		}
+		if ok && !stringIn(ethnicities, nl.Ethnicity) {
+			ethnicities = append(ethnicities, nl.Ethnicity)
+		}
+	}
+	if len(ethnicities) == 0 {
+		if len(lists) == 0 {
+			return ""
+		}
+		if ancestry != defaultNameAncestry && len(wanted) == 0 {
+			log.Warningf("No names found for %s; using %s names.", ancestry, defaultNameAncestry)
+			return randomName(defaultNameAncestry, "", gender, synth)
 		}
-		c.Name = trim(firstName + " " + surname)
+		log.Warningf("No names found for %s %s.", ancestry, ethnicity)
+		ethnicities = append(ethnicities, lists[randomInt(0, len(lists))].Ethnicity)
+	}
+	ethnicity = randomChoice(ethnicities)
+	firstNames := []string{}
+	surnames := []string{}
+	pooled := []string{}
+	neutral := []string{}
+	nicknames := []string{}
+	designations := []string{}
+	for _, nl := range lists {
+		if nl.Ethnicity == ethnicity {
+			switch nl.Type {
+			case gender:
+				firstNames = append(firstNames, nl.Names...)
+			case "Surname":
+				surnames = append(surnames, nl.Names...)
+			case "Neutral":
+				neutral = append(neutral, nl.Names...)
+			case "Nickname":
+				nicknames = append(nicknames, nl.Names...)
+			case "Designation":
+				designations = append(designations, nl.Names...)
+			default:
+				pooled = append(pooled, nl.Names...)
+			}
+		}
+	}
+	pick := randomChoice
+	if synth {
+		pick = synthesizeName
+	}
+	// Serial-style names, e.g., "Cog-217".
+	if len(designations) > 0 {
+		return randomChoice(designations) + "-" + strconv.Itoa(randomInt(1, 1000))
 	}
+	// Genders without dedicated lists draw from neutral names, if any, or
+	// else from every first-name list.
+	if len(firstNames) == 0 {
+		if len(neutral) > 0 {
+			firstNames = neutral
+		} else {
+			firstNames = pooled
+		}
+	}
+	firstName := ""
+	nickname := ""
+	surname := ""
+	if len(firstNames) > 0 {
+		firstName = pick(firstNames)
+	}
+	if len(nicknames) > 0 {
+		nickname = `"` + randomChoice(nicknames) + `"`
+	}
+	if len(surnames) > 0 {
+		surname = pick(surnames)
+	}
+	return trim(firstName + " " + nickname + " " + surname)
 }
 
 // Randomly sample from gender list.
-func (c *Character) setGender(gender string) {
+func (c *Character) setGender(gender, genderList string) {
 	if gender != "" {
 		c.Gender = gender
 	} else {
-		c.Gender = randomChoice(genders)
+		c.Gender = randomChoice(genderChoices(genderList))
+	}
+}
+
+// genderChoices splits a comma-separated gender list, falling back to the
+// default genders if the list has no non-blank entries.
+func genderChoices(genderList string) []string {
+	if list := splitList(genderList); len(list) > 0 {
+		return list
+	}
+	return genders
+}
+
+// Pronouns represents the pronouns used to refer to the character.
+type Pronouns struct {
+	Subject    string `json:"subject"`
+	Object     string `json:"object"`
+	Possessive string `json:"possessive"`
+}
+
+// String returns the pronouns in the short "she/her" form.
+func (p Pronouns) String() string {
+	return p.Subject + "/" + p.Object
+}
+
+var genderPronouns = map[string]Pronouns{
+	"Male":   {"he", "him", "his"},
+	"Female": {"she", "her", "her"},
+}
+
+var neutralPronouns = Pronouns{"they", "them", "their"}
+
+// Sets pronouns from a "subject/object[/possessive]" string, or from the
+// character's gender if none is supplied.
+func (c *Character) setPronouns(pronouns string) {
+	if pronouns == "" {
+		p, ok := genderPronouns[c.Gender]
+		if !ok {
+			p = neutralPronouns
+		}
+		c.Pronouns = p
+		return
+	}
+	parts := strings.Split(pronouns, "/")
+	c.Pronouns = Pronouns{Subject: parts[0], Object: parts[0], Possessive: parts[0]}
+	if len(parts) > 1 {
+		c.Pronouns.Object = parts[1]
+		c.Pronouns.Possessive = parts[1]
+	}
+	if len(parts) > 2 {
+		c.Pronouns.Possessive = parts[2]
 	}
 }
 
@@ -331,19 +527,15 @@ func (c *Character) setArmor()                         {}
 func (c *Character) setEquipment()                     {}
 func (c *Character) setDescription(description string) {}
 func (c *Character) setBackground(background string)   {}
-func (c *Character) setProfessions(professions string) {}
 func (c *Character) setLanguages(languages string)     {}
 
-// Print writes tab-delimited character details to STDOUT.
+// Print writes a plain-text character sheet to STDOUT, wrapped to the width
+// of the terminal.
 func (c Character) Print() {
-	fmt.Println("Name\t" + c.Name)
-	fmt.Println("Gender\t" + c.Gender)
-	fmt.Println("Level\t", c.Level)
-	fmt.Println("Character Seed\t", c.Seed)
-	fmt.Println()
+	c.WriteText(os.Stdout, TermWidth())
 }
 
-// ToJSON writes JSON character details to STDOUT.
+// ToJSON returns the JSON encoding of the character.
 func (c Character) ToJSON(pretty bool) string {
 	var j []byte
 	if pretty {
@@ -351,11 +543,6 @@ func (c Character) ToJSON(pretty bool) string {
 	} else {
 		j, _ = json.Marshal(c)
 	}
-	fmt.Println(string(j))
-	//err := ioutil.WriteFile(fn, j, 0644)
-	//if err != nil {
-	//	panic(err)
-	//}
 	return string(j)
 }
 
@@ -367,27 +554,54 @@ type Opts struct {
 	Description string
 	ExpertPath  string `docopt:"--expert-path"`
 	Gender      string `docopt:"--gender"`
+	Genders     string `docopt:"--genders"`
+	Pronouns    string `docopt:"--pronouns"`
 	Languages   string
 	Level       string `docopt:"--level"`
 	LogLevel    string `docopt:"--log-level"`
+	Format      string `docopt:"--format"`
+	Template    string `docopt:"--template"`
+	Input       string `docopt:"--input"`
 	MasterPath  string `docopt:"--master-path"`
 	Name        string `docopt:"--name"`
+	Ethnicity   string `docopt:"--ethnicity"`
+	NamesFile   string `docopt:"--names-file"`
+	NameGen     string `docopt:"--name-gen"`
 	NovicePath  string `docopt:"--novice-path"`
 	Professions string
 	Seed        string `docopt:"--seed"`
 	DataFile    string `docopt:"--data-file"`
+	// UsedNames, if set, holds names that must not be reused; the new
+	// character's name is added to it.
+	UsedNames *NameSet
+	// Constraints, if any, must all be satisfied by the character; see
+	// ParseConstraint.
+	Constraints []string
+	// masterPaths, if set, narrows the master paths drawn when MasterPath
+	// is empty.
+	masterPaths []string
 }
 
 // NewCharacter generates a SotDL character given a set of user options.
 func NewCharacter(opts Opts) (c Character, err error) {
+	if len(opts.Constraints) > 0 {
+		return newConstrainedCharacter(opts)
+	}
+	return newCharacter(opts)
+}
+
+func newCharacter(opts Opts) (c Character, err error) {
 
 	logging.SetLevel(logLevels[opts.LogLevel], "")
 
 	// Load the character db if empty.
-	if len(db.Paths) == 0 {
-		log.Info("Loading Character DB.")
-		db, err = NewCharDB(opts.DataFile, false)
-		if err != nil {
+	if err = LoadCharDB(opts.DataFile); err != nil {
+		return c, err
+	}
+
+	// Add any user-supplied names.
+	if opts.NamesFile != "" {
+		if err = db.AddNames(opts.NamesFile); err != nil {
 			return c, err
 		}
 	}
@@ -398,18 +612,11 @@ func NewCharacter(opts Opts) (c Character, err error) {
 	// Generate base characteristics
 	log.Info("Generating attributes and characteristics.")
 	c.setPath(opts.Ancestry)
-	c.setGender(opts.Gender)
-	c.setName(opts.Name)
-	c.setLevel(opts.Level)
-	if c.Level > 0 {
-		c.setPath(opts.NovicePath)
-	}
-	if c.Level > 2 {
-		c.setPath(opts.ExpertPath)
-	}
-	if c.Level > 6 {
-		c.setPath(opts.MasterPath)
-	}
+	c.setGender(opts.Gender, opts.Genders)
+	c.setPronouns(opts.Pronouns)
+	c.setName(opts.Name, opts.Ethnicity, opts.NameGen, opts.UsedNames)
+	c.applyAncestryRules(opts)
+	c.advance(parseLevel(opts.Level), opts.pathFor(&c))
 
 	// Generate stuff
 	//c.setMagic()
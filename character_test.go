@@ -46,3 +46,106 @@ func TestNewCharacter(t *testing.T) {
 		}
 	}
 }
+
+func TestNonBinaryNames(t *testing.T) {
+	saved := db
+	defer func() { db = saved }()
+	db = CharDB{Names: []NameList{
+		{Ancestry: "Orc", Ethnicity: "Orc", Type: "Male", Names: []string{"Grom"}},
+		{Ancestry: "Orc", Ethnicity: "Orc", Type: "Female", Names: []string{"Ula"}},
+		{Ancestry: "Orc", Ethnicity: "Orc", Type: "Surname", Names: []string{"Skullsplitter"}},
+	}}
+	name := randomName("Orc", "", "Non-binary", false)
+	if name != "Grom Skullsplitter" && name != "Ula Skullsplitter" {
+		t.Errorf("Expected a pooled first name, got '%s'.", name)
+	}
+	db.Names = append(db.Names,
+		NameList{Ancestry: "Orc", Ethnicity: "Orc", Type: "Neutral", Names: []string{"Drak"}})
+	if name = randomName("Orc", "", "Agender", false); name != "Drak Skullsplitter" {
+		t.Errorf("Expected a neutral first name, got '%s'.", name)
+	}
+}
+
+func TestSetGender(t *testing.T) {
+	for _, list := range []string{"", ",", " , "} {
+		c := Character{}
+		c.setGender("", list)
+		if !stringIn(genders, c.Gender) {
+			t.Errorf("Expected a default gender for list '%s', got '%s'.", list, c.Gender)
+		}
+	}
+	c := Character{}
+	c.setGender("", " , Agender,")
+	if c.Gender != "Agender" {
+		t.Errorf("Expected 'Agender', got '%s'.", c.Gender)
+	}
+}
+
+func TestSetPronouns(t *testing.T) {
+	c := Character{Gender: "Non-binary"}
+	c.setPronouns("")
+	if c.Pronouns.String() != "they/them" {
+		t.Errorf("Incorrect pronouns. Expected 'they/them', got '%s'.", c.Pronouns)
+	}
+	c.setPronouns("xe/xem/xyr")
+	if c.Pronouns.Possessive != "xyr" {
+		t.Errorf("Incorrect possessive. Expected 'xyr', got '%s'.", c.Pronouns.Possessive)
+	}
+}
+
+func TestAncestryNames(t *testing.T) {
+	saved := db
+	defer func() { db = saved }()
+	db = CharDB{}
+	if err := db.AddNames("./assets/sotdl_names.json"); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range ancestries {
+		found := false
+		for _, nl := range db.Names {
+			found = found || nl.Ancestry == a
+		}
+		if !found {
+			t.Errorf("No names found for %s.", a)
+		}
+	}
+	if name := randomName("Clockwork", "", "Male", false); !strings.Contains(name, "-") {
+		t.Errorf("Expected a serial-style Clockwork name, got '%s'.", name)
+	}
+	if name := randomName("Goblin", "", "Female", false); !strings.Contains(name, `"`) {
+		t.Errorf("Expected a Goblin nickname, got '%s'.", name)
+	}
+	northmen := []string{}
+	for _, nl := range db.Names {
+		if nl.Ethnicity == "Northman" && nl.Type == "Male" {
+			northmen = nl.Names
+		}
+	}
+	name := randomName("Human", "Northman", "Male", false)
+	if !stringIn(northmen, strings.Fields(name)[0]) {
+		t.Errorf("Expected a Northman name, got '%s'.", name)
+	}
+}
+
+func TestSecondaryNames(t *testing.T) {
+	saved := db
+	defer func() { db = saved }()
+	db = CharDB{Names: []NameList{
+		{Ancestry: "Human", Ethnicity: "Northman", Type: "Male", Names: []string{"Bjorn"}},
+		{Ancestry: "Human", Ethnicity: "Northman", Type: "Surname", Names: []string{"Ulfsson"}},
+		{Ancestry: "Human", Ethnicity: "Northman", Type: "Male", Names: []string{"Thorne"}, secondary: true},
+		{Ancestry: "Human", Ethnicity: "Khard", Type: "Male", Names: []string{"Yaromir"}, secondary: true},
+		{Ancestry: "Human", Ethnicity: "Khard", Type: "Surname", Names: []string{"Volkov"}, secondary: true},
+	}}
+	for i := 0; i < 20; i++ {
+		if name := randomName("Human", "", "Male", false); name != "Bjorn Ulfsson" {
+			t.Fatalf("Expected a name from the SotDL lists, got '%s'.", name)
+		}
+		if name := randomName("Human", "Northman", "Male", false); name != "Bjorn Ulfsson" {
+			t.Fatalf("Expected the SotDL Northman lists to take precedence, got '%s'.", name)
+		}
+	}
+	if name := randomName("Human", "Khard", "Male", false); name != "Yaromir Volkov" {
+		t.Errorf("Expected a secondary list asked for by ethnicity, got '%s'.", name)
+	}
+}
@@ -22,13 +22,25 @@ var db = CharDB{}
 
 // Data filenames
 var corebookJSON = dataDir + "Shadow_of_the_Demon_Lord.json"
-var namesFile = dataDir + "ik_names.json"
+var namesFile = dataDir + "sotdl_names.json"
+
+// Name lists from other settings, drawn on only for ethnicities asked for by
+// name that namesFile lacks.
+var secondaryNamesFiles = []string{
+	dataDir + "ik_names.json",
+}
 
 // CharDB represents path data extracted from the core rules PDF, along with
-// a list of names for random character naming.
+// a list of names for random character naming and the prerequisites for
+// entering master paths.
 type CharDB struct {
 	Paths map[string]Levels `json:"paths"`
 	Names []NameList        `json:"names"`
+	// Built from masterPathPrereqs and pathTraditions on every load, so
+	// that edits to them reach existing db files.
+	Prereqs    map[string]Prereq   `json:"-"`
+	Traditions map[string][]string `json:"-"`
+	nameFiles  map[string]bool
 }
 
 // Levels is a map of Level structs.
@@ -108,15 +120,49 @@ type NameList struct {
 	Ethnicity string   `json:"ethnicity"`
 	Type      string   `json:"type"`
 	Names     []string `json:"names"`
+	secondary bool
 }
 
-// buildNames reads the name data in from JSON.
+// buildNames reads the bundled name data in from JSON. Existing names are
+// kept if none can be read.
 func (db *CharDB) buildNames() {
 	var names []NameList
-	if err := json.Unmarshal(readJSON(namesFile), &names); err != nil {
-		log.Error(err)
+	for _, fn := range append([]string{namesFile}, secondaryNamesFiles...) {
+		var nls []NameList
+		if err := json.Unmarshal(readJSON(fn), &nls); err != nil {
+			log.Error(fn+":", err)
+			continue
+		}
+		for i := range nls {
+			nls[i].secondary = fn != namesFile
+		}
+		names = append(names, nls...)
+	}
+	if len(names) > 0 {
+		db.Names = names
+	}
+}
+
+// AddNames reads additional name lists from a user-supplied JSON file in the
+// format of the bundled name files. Each file is only added once.
+func (db *CharDB) AddNames(fn string) error {
+	if db.nameFiles[fn] {
+		return nil
+	}
+	raw, err := ioutil.ReadFile(fn)
+	if err != nil {
+		return err
+	}
+	var names []NameList
+	if err := json.Unmarshal(raw, &names); err != nil {
+		return fmt.Errorf("%s: %v", fn, err)
+	}
+	if db.nameFiles == nil {
+		db.nameFiles = map[string]bool{}
 	}
-	db.Names = names
+	db.nameFiles[fn] = true
+	db.Names = append(db.Names, names...)
+	return nil
 }
 
 // Compiles patterns to regular expressions.
@@ -175,6 +221,7 @@ func NewCharDB(pdfFn string, analyze bool) (db CharDB, err error) {
 		db.extract(doc, expertPaths, expertPathLevelPatterns)
 		db.extract(doc, masterPaths, masterPathLevelPatterns)
 		db.buildNames()
+		db.buildPrereqs()
 		db.save()
 		if analyze {
 			db.analyze(doc)
@@ -183,10 +230,45 @@ func NewCharDB(pdfFn string, analyze bool) (db CharDB, err error) {
 		// Load an existing db.
 		log.Info("Loading DB from JSON.")
 		db.load(corebookJSON)
+		db.buildNames()
+		db.buildPrereqs()
 	}
 	return db, nil
 }
 
+// ErrNoCharDB is returned when the character db can neither be loaded from
+// JSON nor extracted from the core rules PDF.
+var ErrNoCharDB = errors.New("character db not available")
+
+// LoadCharDB loads the character db used for generation, if it is not
+// already loaded, extracting it from the core rules PDF if one is given.
+func LoadCharDB(pdfFn string) (err error) {
+	if len(db.Paths) == 0 {
+		log.Info("Loading Character DB.")
+		if db, err = NewCharDB(pdfFn, false); err != nil {
+			if msg := strings.TrimSpace(err.Error()); msg != "" {
+				return fmt.Errorf("%w: %s", ErrNoCharDB, msg)
+			}
+			return ErrNoCharDB
+		}
+	}
+	return nil
+}
+
+// GetCharDB returns the character db used for generation, loading it if
+// necessary.
+func GetCharDB() (*CharDB, error) {
+	if err := LoadCharDB(""); err != nil {
+		return nil, err
+	}
+	return &db, nil
+}
+
+// CharDBFile returns the path of the extracted character db.
+func CharDBFile() string {
+	return corebookJSON
+}
+
 // Build the nested maps.
 func (db *CharDB) initialize() {
 	db.Paths = make(map[string]Levels)
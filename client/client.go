@@ -0,0 +1,233 @@
+// Package client calls the SotDL character generation service, as described
+// by its OpenAPI document.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+// Client calls the service at a base URL, e.g., "http://localhost:8080".
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// New returns a client for the service at the base URL, using the default
+// HTTP client.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// Error is an error response from the service. Details lists the invalid
+// fields of a bad request.
+type Error struct {
+	StatusCode int                   `json:"-"`
+	Message    string                `json:"error"`
+	Details    []sotdlgen.FieldError `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	if len(e.Details) > 0 {
+		msg += ": " + sotdlgen.ValidationError(e.Details).Error()
+	}
+	return msg
+}
+
+// StoredCharacter summarizes a character stored by the service.
+type StoredCharacter struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Ancestry string   `json:"ancestry"`
+	Level    int      `json:"level"`
+	Paths    []string `json:"paths"`
+}
+
+// PathDetail is an ancestry or path together with its levels.
+type PathDetail struct {
+	sotdlgen.PathInfo
+	Details sotdlgen.Levels `json:"details"`
+}
+
+// Filter selects stored characters. Empty fields match every character, as
+// does a nil level.
+type Filter struct {
+	Ancestry string
+	Path     string
+	Level    *int
+}
+
+// do sends a request with an optional JSON body and returns the response,
+// or an *Error if the service does not answer with the expected status.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}, status int) (*http.Response, error) {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != status {
+		defer resp.Body.Close()
+		e := &Error{StatusCode: resp.StatusCode}
+		raw, _ := ioutil.ReadAll(resp.Body)
+		if json.Unmarshal(raw, e) != nil || e.Message == "" {
+			e.Message = strings.TrimSpace(string(raw))
+		}
+		return nil, e
+	}
+	return resp, nil
+}
+
+// doJSON sends a request as do does and decodes the JSON response into v,
+// unless v is nil.
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, body interface{}, status int, v interface{}) (*http.Response, error) {
+	resp, err := c.do(ctx, method, path, query, body, status)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if v != nil {
+		if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return nil, fmt.Errorf("cannot decode response: %v", err)
+		}
+	}
+	return resp, nil
+}
+
+// Generate generates a character. If the service stores characters, the
+// stored character's ID is also returned.
+func (c *Client) Generate(ctx context.Context, req sotdlgen.GenerateRequest) (sotdlgen.Character, string, error) {
+	var ch sotdlgen.Character
+	resp, err := c.doJSON(ctx, http.MethodPost, "/characters", nil, req, http.StatusCreated, &ch)
+	if err != nil {
+		return ch, "", err
+	}
+	id := strings.TrimPrefix(resp.Header.Get("Location"), "/characters/")
+	return ch, id, nil
+}
+
+// Characters lists the stored characters matching the filter.
+func (c *Client) Characters(ctx context.Context, f Filter) ([]StoredCharacter, error) {
+	q := url.Values{}
+	if f.Ancestry != "" {
+		q.Set("ancestry", f.Ancestry)
+	}
+	if f.Path != "" {
+		q.Set("path", f.Path)
+	}
+	if f.Level != nil {
+		q.Set("level", strconv.Itoa(*f.Level))
+	}
+	var chars []StoredCharacter
+	_, err := c.doJSON(ctx, http.MethodGet, "/characters", q, nil, http.StatusOK, &chars)
+	return chars, err
+}
+
+// Character returns a stored character.
+func (c *Client) Character(ctx context.Context, id string) (sotdlgen.Character, error) {
+	var ch sotdlgen.Character
+	_, err := c.doJSON(ctx, http.MethodGet, "/characters/"+url.PathEscape(id), nil, nil, http.StatusOK, &ch)
+	return ch, err
+}
+
+// UpdateCharacter replaces a stored character.
+func (c *Client) UpdateCharacter(ctx context.Context, id string, ch sotdlgen.Character) (sotdlgen.Character, error) {
+	var updated sotdlgen.Character
+	_, err := c.doJSON(ctx, http.MethodPut, "/characters/"+url.PathEscape(id), nil, ch, http.StatusOK, &updated)
+	return updated, err
+}
+
+// DeleteCharacter deletes a stored character.
+func (c *Client) DeleteCharacter(ctx context.Context, id string) error {
+	_, err := c.doJSON(ctx, http.MethodDelete, "/characters/"+url.PathEscape(id), nil, nil, http.StatusNoContent, nil)
+	return err
+}
+
+// Render renders a character in the given format, e.g., "html" or "text".
+func (c *Client) Render(ctx context.Context, ch sotdlgen.Character, format string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodPost, "/render", url.Values{"format": {format}}, ch, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Ancestries lists the ancestries in the service's character db.
+func (c *Client) Ancestries(ctx context.Context) ([]sotdlgen.PathInfo, error) {
+	var infos []sotdlgen.PathInfo
+	_, err := c.doJSON(ctx, http.MethodGet, "/ancestries", nil, nil, http.StatusOK, &infos)
+	return infos, err
+}
+
+// Paths lists the paths of the given tier, or all paths if the tier is
+// empty.
+func (c *Client) Paths(ctx context.Context, tier string) ([]sotdlgen.PathInfo, error) {
+	q := url.Values{}
+	if tier != "" {
+		q.Set("tier", tier)
+	}
+	var infos []sotdlgen.PathInfo
+	_, err := c.doJSON(ctx, http.MethodGet, "/paths", q, nil, http.StatusOK, &infos)
+	return infos, err
+}
+
+// Path describes an ancestry or path, level by level.
+func (c *Client) Path(ctx context.Context, name string) (PathDetail, error) {
+	var detail PathDetail
+	_, err := c.doJSON(ctx, http.MethodGet, "/paths/"+url.PathEscape(name), nil, nil, http.StatusOK, &detail)
+	return detail, err
+}
+
+// Names lists the name lists, optionally for a single ancestry, ethnicity or
+// type.
+func (c *Client) Names(ctx context.Context, ancestry, ethnicity, typ string) ([]sotdlgen.NameList, error) {
+	q := url.Values{}
+	for k, v := range map[string]string{"ancestry": ancestry, "ethnicity": ethnicity, "type": typ} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	var lists []sotdlgen.NameList
+	_, err := c.doJSON(ctx, http.MethodGet, "/names", q, nil, http.StatusOK, &lists)
+	return lists, err
+}
+
+// Genders lists the default genders.
+func (c *Client) Genders(ctx context.Context) ([]string, error) {
+	var genders []string
+	_, err := c.doJSON(ctx, http.MethodGet, "/genders", nil, nil, http.StatusOK, &genders)
+	return genders, err
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+func TestGenerate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/characters" {
+			t.Errorf("Unexpected request %s %s.", r.Method, r.URL.Path)
+		}
+		var req sotdlgen.GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		w.Header().Set("Location", "/characters/7")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(sotdlgen.Character{Name: "Vesna", Ancestry: req.Ancestry})
+	}))
+	defer srv.Close()
+	c, id, err := New(srv.URL).Generate(context.Background(), sotdlgen.GenerateRequest{Ancestry: "Dwarf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "Vesna" || c.Ancestry != "Dwarf" || id != "7" {
+		t.Errorf("Unexpected character %s (%s), id '%s'.", c.Name, c.Ancestry, id)
+	}
+}
+
+func TestError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "invalid request", "details": [{"field": "level", "message": "must be in [0..10], got 11"}]}`))
+	}))
+	defer srv.Close()
+	_, _, err := New(srv.URL).Generate(context.Background(), sotdlgen.GenerateRequest{})
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatalf("Expected an *Error, got %v.", err)
+	}
+	if e.StatusCode != http.StatusBadRequest || len(e.Details) != 1 || e.Details[0].Field != "level" {
+		t.Errorf("Unexpected error %+v.", e)
+	}
+}
+
+func TestCharacters(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("ancestry") != "Orc" || q.Get("level") != "3" || q.Get("path") != "" {
+			t.Errorf("Unexpected query '%s'.", r.URL.RawQuery)
+		}
+		w.Write([]byte(`[{"id": "1", "name": "Grub", "ancestry": "Orc", "level": 3, "paths": ["Warrior", "Fighter"]}]`))
+	}))
+	defer srv.Close()
+	level := 3
+	chars, err := New(srv.URL).Characters(context.Background(), Filter{Ancestry: "Orc", Level: &level})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chars) != 1 || chars[0].ID != "1" || len(chars[0].Paths) != 2 {
+		t.Errorf("Unexpected characters %+v.", chars)
+	}
+}
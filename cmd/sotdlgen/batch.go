@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+var batchUsage = `SotDL Character Generator: Batch Generation
+
+Generates a roster of characters with distinct names. Options that are set
+apply to every character; the rest are randomized per character. Each
+character's seed is derived from the master seed, so a batch can be
+regenerated exactly.
+
+Usage: sotdl batch [options]
+
+Options:
+  -n, --count=<int>         Number of characters to generate. [default: 10]
+  -o, --output=<str>        One of {jsonl, csv}. [default: jsonl]
+  -g, --gender=<str>        The characters' gender.
+  --genders=<list>          Comma-delimited genders to choose from.
+  -l, --level=<int>         The characters' level; random if not specified.
+  -A, --ancestry=<str>      The characters' 0th lvl path (e.g., Human).
+  -N, --novice-path=<str>   The characters' 1st lvl path (e.g., Rogue).
+  -E, --expert-path=<str>   The characters' 3rd lvl path (e.g., Fighter).
+  -M, --master-path=<str>   The characters' 7th lvl path (e.g., Myrmidon).
+  -e, --ethnicity=<list>    Comma-delimited ethnicities to draw names from.
+  --names-file=<path>       Additional JSON name lists to draw names from.
+  --name-gen=<str>          One of {list, markov}. [default: list]
+  -w, --where=<exprs>       Semicolon-delimited constraints for every
+                            character to satisfy (e.g., "strength >= 12").
+  -s, --seed=<hex>          Master seed for the batch.
+  -d, --data-file=<path>    SotDL Core Rules PDF file.
+  --log-level=<str>         One of {INFO, WARNING, ERROR}. [default: ERROR]
+  -h --help
+`
+
+func batch(argv []string) int {
+	opts := sotdlgen.Opts{}
+	optFlags := parse(batchUsage, argv)
+	count, err := optFlags.Int("--count")
+	if err != nil {
+		return usageError("invalid --count '%v'", optFlags["--count"])
+	}
+	output, _ := optFlags.String("--output")
+	opts.Constraints = constraints(optFlags)
+	if err = bind(optFlags, &opts, "batch", "--count", "--output"); err != nil {
+		return usageError("%v", err)
+	}
+	if output != "csv" && output != "jsonl" {
+		return usageError("unknown output '%s'; expected one of {jsonl, csv}", output)
+	}
+
+	chars, err := sotdlgen.NewBatch(opts, count)
+	if err != nil {
+		return fail(err)
+	}
+	if output == "csv" {
+		err = sotdlgen.WriteCSV(os.Stdout, chars)
+	} else {
+		err = sotdlgen.WriteJSONL(os.Stdout, chars)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
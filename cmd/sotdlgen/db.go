@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+var dbUsage = `SotDL Character Generator: Character DB
+
+Builds the character db from the SotDL core rules PDF (which requires
+pdftotext), or summarizes the db already built.
+
+Usage:
+  sotdl db build [options] <pdf>
+  sotdl db info
+
+Options:
+  --analyze                 Report the extraction coverage of each path.
+  --log-level=<str>         One of {INFO, WARNING, ERROR}. [default: ERROR]
+  -h --help
+`
+
+func database(argv []string) int {
+	optFlags := parse(dbUsage, argv)
+	if build, _ := optFlags.Bool("build"); build {
+		pdf, _ := optFlags.String("<pdf>")
+		analyze, _ := optFlags.Bool("--analyze")
+		if _, err := os.Stat(pdf); err != nil {
+			return fail(err)
+		}
+		if _, err := sotdlgen.NewCharDB(pdf, analyze); err != nil {
+			return fail(fmt.Errorf("%w: %v", sotdlgen.ErrNoCharDB, err))
+		}
+		fmt.Println("Character db extracted to", sotdlgen.CharDBFile())
+		return exitOK
+	}
+	db, err := sotdlgen.GetCharDB()
+	if err != nil {
+		return fail(err)
+	}
+	row := func(label string, value interface{}) {
+		fmt.Printf("%-14s %v\n", label+":", value)
+	}
+	row("File", sotdlgen.CharDBFile())
+	row("Version", sotdlgen.VERSION)
+	counts := map[string]int{}
+	levels := 0
+	for name, lvls := range db.Paths {
+		counts[sotdlgen.PathTier(name)]++
+		levels += len(lvls)
+	}
+	for _, t := range []struct{ tier, label string }{
+		{"ancestry", "Ancestries"}, {"novice", "Novice paths"},
+		{"expert", "Expert paths"}, {"master", "Master paths"},
+	} {
+		row(t.label, counts[t.tier])
+	}
+	row("Levels", levels)
+	names, ancestries := 0, map[string]bool{}
+	for _, nl := range db.Names {
+		names += len(nl.Names)
+		ancestries[nl.Ancestry] = true
+	}
+	row("Name lists", fmt.Sprintf("%d (%d names for %d ancestries)", len(db.Names), names, len(ancestries)))
+	row("Prerequisites", fmt.Sprintf("%d master paths", len(db.Prereqs)))
+	row("Traditions", fmt.Sprintf("%d paths", len(db.Traditions)))
+	return exitOK
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+var generateUsage = `SotDL Character Generator: Generation
+
+Usage:
+  sotdl generate [options]
+  sotdl [options]
+
+Options:
+  -n, --name=<str>          The character's full name; random if not specified.
+  -e, --ethnicity=<list>    Comma-delimited ethnicities to draw names from
+                            (e.g., Northman); by ancestry if not specified.
+                            Iron Kingdoms ethnicities (e.g., Khard) are only
+                            drawn from when named here.
+  --names-file=<path>       Additional JSON name lists to draw names from.
+  --name-gen=<str>          One of {list, markov}; markov synthesizes novel
+                            names from the name lists. [default: list]
+  -g, --gender=<str>        The character's gender.
+  --genders=<list>          Comma-delimited genders to choose from when none is
+                            specified (e.g., "Male,Female,Non-binary").
+  --pronouns=<str>          The character's pronouns (e.g., "they/them/their").
+  -l, --level=<int>         The character's level; 0 if not specified, or
+                            random if there are constraints.
+  -A, --ancestry=<str>      The character's 0th lvl path (e.g., Human).
+  -N, --novice-path=<str>   The character's 1st lvl path (e.g., Rogue).
+  -E, --expert-path=<str>   The character's 3rd lvl path (e.g., Fighter).
+  -M, --master-path=<str>   The character's 7th lvl path (e.g., Myrmidon).
+  -w, --where=<exprs>       Semicolon-delimited constraints to satisfy (e.g.,
+                            "ancestry in Dwarf,Orc; strength >= 12; no Wizard").
+  -s, --seed=<hex>          Character generation signature. A character
+                            generated with constraints reproduces only with
+                            the same --where.
+  -d, --data-file=<path>    SotDL Core Rules PDF file to extract the character
+                            db from, if it has not been built.
+  -f, --format=<str>        One of {json, yaml, markdown, text, pdf, html,
+                            foundry}. [default: json]
+  -t, --template=<path>     A text/template or html/template (.html) file, or
+                            a bundled template name, to render the character
+                            with; overrides --format.
+  --log-level=<str>         One of {INFO, WARNING, ERROR}. [default: ERROR]
+  -h --help
+  --version
+`
+
+func generate(argv []string) int {
+	opts := sotdlgen.Opts{}
+	optFlags := parse(generateUsage, argv)
+	opts.Constraints = constraints(optFlags)
+	if err := bind(optFlags, &opts, "generate"); err != nil {
+		return usageError("%v", err)
+	}
+	if opts.Level == "" && len(opts.Constraints) == 0 {
+		opts.Level = "0"
+	}
+	r, err := renderer(opts.Format, opts.Template)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	c, err := sotdlgen.NewCharacter(opts)
+	if err != nil {
+		return fail(err)
+	}
+	if err = r.Render(os.Stdout, c); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
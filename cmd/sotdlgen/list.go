@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+var listUsage = `SotDL Character Generator: List
+
+Lists the values accepted by the generator's options.
+
+Usage:
+  sotdl list ancestries
+  sotdl list paths [--tier=<tier>]
+  sotdl list names [--ancestry=<str>]
+  sotdl list formats
+  sotdl list templates
+
+Options:
+  --tier=<tier>             One of {novice, expert, master}; all if not
+                            specified.
+  --ancestry=<str>          Only list names for the ancestry.
+  -h --help
+`
+
+func list(argv []string) int {
+	optFlags := parse(listUsage, argv)
+	var items []string
+	switch {
+	case optFlags["ancestries"] == true:
+		items = sotdlgen.Ancestries()
+	case optFlags["paths"] == true:
+		tier, _ := optFlags.String("--tier")
+		paths, err := sotdlgen.PathsByTier(tier)
+		if err != nil {
+			return usageError("%v", err)
+		}
+		items = paths
+	case optFlags["names"] == true:
+		ancestry, _ := optFlags.String("--ancestry")
+		db, err := sotdlgen.GetCharDB()
+		if err != nil {
+			return fail(err)
+		}
+		for _, nl := range db.NameLists(ancestry) {
+			label := nl.Ancestry
+			if nl.Ethnicity != "" && nl.Ethnicity != nl.Ancestry {
+				label += " " + nl.Ethnicity
+			}
+			label = strings.TrimSpace(label + " " + nl.Type)
+			items = append(items, fmt.Sprintf("%s: %s", label, strings.Join(nl.Names, ", ")))
+		}
+		if len(items) == 0 {
+			return fail(fmt.Errorf("no names for ancestry '%s'", ancestry))
+		}
+	case optFlags["formats"] == true:
+		items = sotdlgen.RendererNames()
+	case optFlags["templates"] == true:
+		items = sotdlgen.BundledTemplates()
+	}
+	for _, item := range items {
+		fmt.Println(item)
+	}
+	return exitOK
+}
+
+var showUsage = `SotDL Character Generator: Show
+
+Shows the character db entry for an ancestry or path, level by level.
+
+Usage: sotdl show path [options] <name>
+
+Options:
+  -l, --level=<int>         Only show the given level.
+  -f, --format=<str>        One of {text, json}. [default: text]
+  -h --help
+`
+
+func show(argv []string) int {
+	optFlags := parse(showUsage, argv)
+	name, _ := optFlags.String("<name>")
+	format, _ := optFlags.String("--format")
+	level := -1
+	if l, _ := optFlags.String("--level"); l != "" {
+		var err error
+		if level, err = strconv.Atoi(l); err != nil {
+			return usageError("invalid --level '%s'", l)
+		}
+	}
+	path, ok := sotdlgen.LookupPath(name)
+	if !ok {
+		return fail(sotdlgen.ValidationError{{Field: "path", Message: fmt.Sprintf("unknown path '%s'", name)}})
+	}
+	db, err := sotdlgen.GetCharDB()
+	if err != nil {
+		return fail(err)
+	}
+	switch format {
+	case "text":
+		err = db.WritePath(os.Stdout, path, level, sotdlgen.TermWidth())
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if lvl, ok := db.Paths[path][level]; ok {
+			err = enc.Encode(lvl)
+		} else {
+			err = enc.Encode(db.Paths[path])
+		}
+	default:
+		return usageError("unknown format '%s'; expected one of {text, json}", format)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+var searchUsage = `SotDL Character Generator: Search
+
+Searches the talents and the language and profession text of every ancestry
+and path for entries containing all the given words, ignoring case.
+
+Usage:
+  sotdl search talent [options] <words>...
+  sotdl search profession [options] <words>...
+  sotdl search [options] <words>...
+
+Options:
+  -f, --format=<str>        One of {text, json}. [default: text]
+  -h --help
+`
+
+func search(argv []string) int {
+	optFlags := parse(searchUsage, argv)
+	words := optFlags["<words>"].([]string)
+	format, _ := optFlags.String("--format")
+	kind := ""
+	for _, k := range []string{sotdlgen.SearchTalents, sotdlgen.SearchProfessions} {
+		if optFlags[k] == true {
+			kind = k
+		}
+	}
+	db, err := sotdlgen.GetCharDB()
+	if err != nil {
+		return fail(err)
+	}
+	results, err := db.Search(kind, strings.Join(words, " "))
+	if err != nil {
+		return usageError("%v", err)
+	}
+	switch format {
+	case "text":
+		err = sotdlgen.WriteSearchResults(os.Stdout, results, sotdlgen.TermWidth())
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(results)
+	default:
+		return usageError("unknown format '%s'; expected one of {text, json}", format)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "sotdl: no matches")
+		return exitFailure
+	}
+	return exitOK
+}
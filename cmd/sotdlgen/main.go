@@ -2,44 +2,174 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/docopt/docopt-go"
 	"github.com/gruevyhat/sotdlgen"
+	logging "github.com/op/go-logging"
 )
 
 var usage = `SotDL Character Generator
 
-Usage: sotdl [options]
-
-Options:
-  -n, --name=<str>          The character's full name; random if not specified.
-  -g, --gender=<str>        The character's gender.
-  -l, --level=<int>         The character's level. [default: 0]
-  -A, --ancestry=<str>      The character's 0th lvl path (e.g., Human).
-  -N, --novice-path=<str>   The character's 1st lvl path (e.g., Rogue). 
-  -E, --expert-path=<str>   The character's 3rd lvl path (e.g., Fighter).
-  -M, --master-path=<str>   The character's 7th lvl path (e.g., Myrmidon).
-  -s, --seed=<hex>          Character generation signature.
-  -d, --data-file=<path>    SotDL Core Rules PDF file.
-  --log-level=<str>         One of {INFO, WARNING, ERROR}. [default: ERROR]
-  -h --help
-  --version
+Usage:
+  sotdl <command> [<args>...]
+  sotdl [options]
+  sotdl -h | --help
+  sotdl --version
+
+Commands:
+  generate    Generate a character (the default).
+  batch       Generate many characters at once.
+  party       Generate a balanced party.
+  wizard      Create a character step by step.
+  levelup     Advance a saved character.
+  render      Render a saved character in another format.
+  validate    Check saved characters against the character db.
+  db build    Extract the character db from the core rules PDF.
+  db info     Summarize the character db.
+  list        List ancestries, paths, names, formats or templates.
+  show        Show the levels of a path.
+  search      Search talents and languages and professions.
+
+Run "sotdl <command> --help" for the options of each command.
+
+Exit status:
+  0  Success.
+  1  Generation, rendering or I/O failed.
+  2  Invalid command line.
+  3  Invalid character, options or constraints.
+  4  The character db is not available.
 `
 
+// Exit codes.
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+	exitInvalid = 3
+	exitNoDB    = 4
+)
+
+// Commands, keyed by name. Each takes its arguments, starting with its own
+// name, and returns an exit code.
+var commands = map[string]func(argv []string) int{
+	"generate": generate,
+	"batch":    batch,
+	"party":    party,
+	"wizard":   wizard,
+	"levelup":  levelup,
+	"render":   render,
+	"validate": validate,
+	"db":       database,
+	"list":     list,
+	"show":     show,
+	"search":   search,
+}
+
 func main() {
-	opts := sotdlgen.Opts{}
-	optFlags, _ := docopt.ParseArgs(usage, nil, sotdlgen.VERSION)
-	optFlags.Bind(&opts)
-	c, err := sotdlgen.NewCharacter(opts)
+	logging.SetLevel(logging.ERROR, "")
+	// Text sheets written by the CLI fit the terminal.
+	sotdlgen.RegisterRenderer("text", sotdlgen.TextRenderer{Width: sotdlgen.TermWidth()})
+	argv := os.Args[1:]
+	if len(argv) == 0 || (strings.HasPrefix(argv[0], "-") && !isHelpFlag(argv[0])) {
+		os.Exit(generate(append([]string{"generate"}, argv...)))
+	}
+	if cmd, ok := commands[argv[0]]; ok {
+		os.Exit(cmd(argv))
+	}
+	parse(usage, argv)
+	os.Exit(usageError("unknown command '%s'; run \"sotdl --help\" for a list", argv[0]))
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "-h" || arg == "--help" || arg == "--version"
+}
+
+// parser prints help to STDOUT, and usage errors to STDERR with exitUsage.
+var parser = &docopt.Parser{
+	HelpHandler: func(err error, usage string) {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, usage)
+			os.Exit(exitUsage)
+		}
+		fmt.Println(strings.TrimSpace(usage))
+		os.Exit(exitOK)
+	},
+}
+
+func parse(usage string, argv []string) docopt.Opts {
+	optFlags, _ := parser.ParseArgs(usage, argv, sotdlgen.VERSION)
+	return optFlags
+}
+
+// fail reports the error on STDERR and returns the matching exit code.
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, "sotdl:", err)
+	var verr sotdlgen.ValidationError
+	var cerr *sotdlgen.ConstraintError
+	if errors.As(err, &verr) || errors.As(err, &cerr) {
+		return exitInvalid
+	}
+	if errors.Is(err, sotdlgen.ErrNoCharDB) {
+		return exitNoDB
+	}
+	return exitFailure
+}
+
+// usageError reports an invalid argument and returns exitUsage.
+func usageError(format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, "sotdl: "+format+"\n", args...)
+	return exitUsage
+}
+
+// renderer returns the named bundled or file template, if given, and else
+// the renderer for the format.
+func renderer(format, template string) (sotdlgen.Renderer, error) {
+	if template != "" {
+		return sotdlgen.NewTemplateRenderer(template)
+	}
+	return sotdlgen.GetRenderer(format)
+}
+
+func loadCharacter(fn string) (sotdlgen.Character, error) {
+	f, err := os.Open(fn)
 	if err != nil {
-		fmt.Println("An error has occurred. Aborting.")
-		os.Exit(1)
+		return sotdlgen.Character{}, err
+	}
+	defer f.Close()
+	return sotdlgen.LoadCharacter(f)
+}
+
+// constraints removes the --where option from the parsed options, returning
+// its constraints.
+func constraints(optFlags docopt.Opts) []string {
+	where, _ := optFlags.String("--where")
+	delete(optFlags, "--where")
+	ks := []string{}
+	for _, k := range strings.Split(where, ";") {
+		if k = strings.TrimSpace(k); k != "" {
+			ks = append(ks, k)
+		}
+	}
+	return ks
+}
+
+// bind binds the options to opts, ignoring the given keys, e.g., command
+// names and options with no Opts field, and checks those that docopt
+// cannot.
+func bind(optFlags docopt.Opts, opts *sotdlgen.Opts, ignore ...string) error {
+	for _, key := range ignore {
+		delete(optFlags, key)
+	}
+	if err := optFlags.Bind(opts); err != nil {
+		return err
 	}
-	if opts.DataFile != "" {
-		fmt.Println("Database extracted from file.")
-	} else {
-		c.ToJSON(true)
+	if g := opts.NameGen; g != "" && g != sotdlgen.NameGenList && g != sotdlgen.NameGenMarkov {
+		return fmt.Errorf("--name-gen must be one of {%s, %s}, got '%s'",
+			sotdlgen.NameGenList, sotdlgen.NameGenMarkov, g)
 	}
+	return nil
 }
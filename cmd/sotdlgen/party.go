@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+var partyUsage = `SotDL Character Generator: Party Generation
+
+Generates a party of characters of the same level. Each role is taken by one
+member in turn; by default the four novice paths are covered. Members have
+distinct names and, where possible, distinct ancestries.
+
+Usage: sotdl party [options]
+
+Options:
+  -n, --size=<int>          Number of party members. [default: 4]
+  -r, --roles=<list>        Comma-delimited novice or expert paths for the
+                            members to take (e.g., "Warrior,Priest,Wizard").
+  -l, --level=<int>         The party's level; random if not specified.
+  -A, --ancestry=<str>      The members' 0th lvl path, if all are to share it.
+  --genders=<list>          Comma-delimited genders to choose from.
+  -e, --ethnicity=<list>    Comma-delimited ethnicities to draw names from.
+  --names-file=<path>       Additional JSON name lists to draw names from.
+  --name-gen=<str>          One of {list, markov}. [default: list]
+  -f, --format=<str>        One of {json, yaml, markdown, text}. [default: json]
+  -w, --where=<exprs>       Semicolon-delimited constraints for every
+                            character to satisfy (e.g., "strength >= 12").
+  -s, --seed=<hex>          Master seed for the party.
+  -d, --data-file=<path>    SotDL Core Rules PDF file.
+  --log-level=<str>         One of {INFO, WARNING, ERROR}. [default: ERROR]
+  -h --help
+`
+
+func party(argv []string) int {
+	opts := sotdlgen.Opts{}
+	optFlags := parse(partyUsage, argv)
+	size, err := optFlags.Int("--size")
+	if err != nil {
+		return usageError("invalid --size '%v'", optFlags["--size"])
+	}
+	roles, _ := optFlags.String("--roles")
+	opts.Constraints = constraints(optFlags)
+	if err = bind(optFlags, &opts, "party", "--size", "--roles"); err != nil {
+		return usageError("%v", err)
+	}
+
+	var roleList []string
+	for _, role := range strings.Split(roles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roleList = append(roleList, role)
+		}
+	}
+	p, err := sotdlgen.NewParty(opts, size, roleList)
+	if err != nil {
+		return fail(err)
+	}
+	if err = p.Render(os.Stdout, opts.Format); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+var levelupUsage = `SotDL Character Generator: Level Up
+
+Advances a saved character (sotdlgen JSON or Foundry VTT actor), adding the
+benefits of each level gained and entering new paths as their tiers open.
+
+Usage: sotdl levelup [options] <file>
+
+Options:
+  --to=<int>                The level to advance to; the next level if not
+                            specified.
+  -N, --novice-path=<str>   The 1st lvl path to take, if reached.
+  -E, --expert-path=<str>   The 3rd lvl path to take, if reached.
+  -M, --master-path=<str>   The 7th lvl path to take, if reached.
+  -s, --seed=<hex>          Seed for the random choices; derived from the
+                            character's seed if not specified.
+  -f, --format=<str>        One of {json, yaml, markdown, text, pdf, html,
+                            foundry}. [default: json]
+  -t, --template=<path>     A template to render the character with.
+  --log-level=<str>         One of {INFO, WARNING, ERROR}. [default: ERROR]
+  -h --help
+`
+
+func levelup(argv []string) int {
+	opts := sotdlgen.Opts{}
+	optFlags := parse(levelupUsage, argv)
+	fn, _ := optFlags.String("<file>")
+	to, _ := optFlags.String("--to")
+	if err := bind(optFlags, &opts, "levelup", "<file>", "--to"); err != nil {
+		return usageError("%v", err)
+	}
+	r, err := renderer(opts.Format, opts.Template)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	c, err := loadCharacter(fn)
+	if err != nil {
+		return fail(err)
+	}
+	level := c.Level + 1
+	if to != "" {
+		if level, err = strconv.Atoi(to); err != nil {
+			return usageError("invalid --to '%s'", to)
+		}
+	}
+	if err = c.LevelUp(level, opts); err != nil {
+		return fail(err)
+	}
+	if err = r.Render(os.Stdout, c); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+var renderUsage = `SotDL Character Generator: Render
+
+Renders a saved character (sotdlgen JSON or Foundry VTT actor).
+
+Usage: sotdl render [options] <file>
+
+Options:
+  -f, --format=<str>        One of {json, yaml, markdown, text, pdf, html,
+                            foundry}. [default: text]
+  -t, --template=<path>     A text/template or html/template (.html) file, or
+                            a bundled template name, to render the character
+                            with; overrides --format.
+  -h --help
+`
+
+func render(argv []string) int {
+	optFlags := parse(renderUsage, argv)
+	fn, _ := optFlags.String("<file>")
+	format, _ := optFlags.String("--format")
+	template, _ := optFlags.String("--template")
+	r, err := renderer(format, template)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	c, err := loadCharacter(fn)
+	if err != nil {
+		return fail(err)
+	}
+	if err = r.Render(os.Stdout, c); err != nil {
+		return fail(err)
+	}
+	return exitOK
+}
+
+var validateUsage = `SotDL Character Generator: Validate
+
+Checks saved characters (sotdlgen JSON or Foundry VTT actors) against the
+character db, reporting each invalid field. Exits with status 3 if any
+character is invalid.
+
+Usage: sotdl validate [options] <file>...
+
+Options:
+  -q, --quiet               Only report invalid characters.
+  -h --help
+`
+
+func validate(argv []string) int {
+	optFlags := parse(validateUsage, argv)
+	quiet, _ := optFlags.Bool("--quiet")
+	code := exitOK
+	for _, fn := range optFlags["<file>"].([]string) {
+		_, err := loadCharacter(fn)
+		switch e := err.(type) {
+		case nil:
+			if !quiet {
+				fmt.Printf("%s: ok\n", fn)
+			}
+		case sotdlgen.ValidationError:
+			for _, fe := range e {
+				fmt.Printf("%s: %s: %s\n", fn, fe.Field, fe.Message)
+			}
+			code = exitInvalid
+		default:
+			if c := fail(fmt.Errorf("%s: %w", fn, err)); code == exitOK || c == exitNoDB {
+				code = c
+			}
+		}
+	}
+	return code
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+var wizardUsage = `SotDL Character Generator: Wizard
+
+Walks through creating a character step by step: ancestry, gender, name,
+level, each path tier, attribute increases and professions. Pick an option
+by name or number at each step, or press Enter for a random pick. The
+character is shown as it evolves and saved as JSON at the end; an existing
+file is only overwritten if you confirm it.
+
+Usage: sotdl wizard [options]
+
+Options:
+  -o, --output=<path>       The file to save the character to; named after the
+                            character if not specified.
+  -e, --ethnicity=<list>    Comma-delimited ethnicities to draw names from.
+  --names-file=<path>       Additional JSON name lists to draw names from.
+  --name-gen=<str>          One of {list, markov}. [default: list]
+  --genders=<list>          Comma-delimited genders to choose from.
+  -s, --seed=<hex>          Seed for the random picks.
+  -d, --data-file=<path>    SotDL Core Rules PDF file.
+  --log-level=<str>         One of {INFO, WARNING, ERROR}. [default: ERROR]
+  -h --help
+`
+
+func wizard(argv []string) int {
+	opts := sotdlgen.Opts{}
+	optFlags := parse(wizardUsage, argv)
+	output, _ := optFlags.String("--output")
+	if err := bind(optFlags, &opts, "wizard", "--output"); err != nil {
+		return usageError("%v", err)
+	}
+	p := &termPrompter{in: bufio.NewReader(os.Stdin), out: os.Stdout}
+	c, err := sotdlgen.BuildCharacter(opts, p)
+	if err == io.EOF {
+		fmt.Fprintln(os.Stderr, "\nsotdl: aborted; the character was not saved")
+		return exitFailure
+	} else if err != nil {
+		return fail(err)
+	}
+	if output == "" {
+		output = fileName(c.Name) + ".json"
+	}
+	f, err := p.create(output)
+	if err == io.EOF || (err == nil && f == nil) {
+		fmt.Fprintln(os.Stderr, "\nsotdl: the character was not saved")
+		return exitFailure
+	} else if err != nil {
+		return fail(err)
+	}
+	defer f.Close()
+	if err = c.Render(f, "json"); err != nil {
+		return fail(err)
+	}
+	fmt.Fprintln(p.out, "\nSaved to", f.Name())
+	return exitOK
+}
+
+// fileName turns a character name into a file name.
+func fileName(name string) string {
+	fn := strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-':
+			return unicode.ToLower(r)
+		case unicode.IsSpace(r):
+			return '_'
+		}
+		return -1
+	}, name)
+	if fn == "" {
+		fn = "character"
+	}
+	return fn
+}
+
+// termPrompter asks for each choice on the terminal.
+type termPrompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *termPrompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// create creates the file to save the character to. If the file exists, it
+// asks whether to overwrite it or else where to save instead, and returns a
+// nil file if told not to save.
+func (p *termPrompter) create(path string) (*os.File, error) {
+	for path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+		if !os.IsExist(err) {
+			return f, err
+		}
+		fmt.Fprintf(p.out, "\n%s exists. Overwrite it? [y/N]: ", path)
+		answer, err := p.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(answer, "y") || strings.EqualFold(answer, "yes") {
+			return os.Create(path)
+		}
+		fmt.Fprint(p.out, "Save to another file instead [don't save]: ")
+		if path, err = p.readLine(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (p *termPrompter) Choose(step string, options []string) (string, error) {
+	fmt.Fprintf(p.out, "\n%s:\n", step)
+	width := 0
+	for _, o := range options {
+		if len(o) > width {
+			width = len(o)
+		}
+	}
+	cols := sotdlgen.TermWidth() / (width + 8)
+	if cols < 1 {
+		cols = 1
+	}
+	for i, o := range options {
+		fmt.Fprintf(p.out, "  %3d) %-*s", i+1, width, o)
+		if (i+1)%cols == 0 || i == len(options)-1 {
+			fmt.Fprintln(p.out)
+		}
+	}
+	for {
+		fmt.Fprint(p.out, "Choose a number or name [random]: ")
+		answer, err := p.readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			return "", nil
+		}
+		for _, o := range options {
+			if strings.EqualFold(o, answer) {
+				return o, nil
+			}
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			return options[n-1], nil
+		}
+		fmt.Fprintf(p.out, "'%s' is not one of the options.\n", answer)
+	}
+}
+
+func (p *termPrompter) Ask(step string) (string, error) {
+	fmt.Fprintf(p.out, "\n%s (optional): ", step)
+	return p.readLine()
+}
+
+func (p *termPrompter) Show(c sotdlgen.Character) {
+	fmt.Fprintln(p.out)
+	c.WriteText(p.out, sotdlgen.TermWidth())
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/gruevyhat/sotdlgen"
+)
+
+// characters stores generated characters, if storage is enabled.
+var characters *store
+
+// charStore returns the character store, or writes a 501 response if
+// storage is disabled.
+func charStore(w http.ResponseWriter) (*store, bool) {
+	if characters == nil {
+		writeError(w, http.StatusNotImplemented,
+			fmt.Errorf("character storage is disabled; start the service with --store FILE"))
+		return nil, false
+	}
+	return characters, true
+}
+
+// Generates a character from a JSON options document, storing it if storage
+// is enabled. The renderer is chosen first, so that a bad format or template
+// stores nothing.
+func createCharacter(w http.ResponseWriter, r *http.Request) {
+	rend, err := renderer(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	var req sotdlgen.GenerateRequest
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err = dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+	if dec.More() {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: trailing data"))
+		return
+	}
+	c, err := generateCharacter(req)
+	if err != nil {
+		writeGenerateError(w, r, err)
+		return
+	}
+	if characters != nil {
+		id, err := characters.add(c)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("cannot store character: %v", err))
+			return
+		}
+		w.Header().Set("Location", "/characters/"+id)
+	}
+	writeRendered(w, r, http.StatusCreated, rend, c)
+}
+
+// Lists the stored characters, optionally filtered by ancestry, path and
+// level.
+func listCharacters(w http.ResponseWriter, r *http.Request) {
+	s, ok := charStore(w)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	f := storeFilter{Ancestry: q.Get("ancestry"), Path: q.Get("path"), Level: -1}
+	if l := q.Get("level"); l != "" {
+		level, err := strconv.Atoi(l)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorBody{"invalid request",
+				[]sotdlgen.FieldError{{Field: "level", Message: fmt.Sprintf("not a number: '%s'", l)}}})
+			return
+		}
+		f.Level = level
+	}
+	writeJSON(w, http.StatusOK, s.list(f))
+}
+
+// Shows a stored character.
+func getCharacter(w http.ResponseWriter, r *http.Request) {
+	s, ok := charStore(w)
+	if !ok {
+		return
+	}
+	id := mux.Vars(r)["id"]
+	raw, ok := s.get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no character '%s'", id))
+		return
+	}
+	c, err := loadCharacter(raw)
+	switch {
+	case errors.Is(err, sotdlgen.ErrNoCharDB):
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	case err != nil:
+		// The client sent nothing invalid: the stored record is corrupt.
+		log.Errorf("request_id=%s stored character '%s': %v", requestID(r), id, err)
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("stored character '%s' cannot be loaded", id))
+		return
+	}
+	writeCharacter(w, r, http.StatusOK, c)
+}
+
+// Replaces a stored character with the character in the request body.
+func putCharacter(w http.ResponseWriter, r *http.Request) {
+	s, ok := charStore(w)
+	if !ok {
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if _, ok := s.get(id); !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no character '%s'", id))
+		return
+	}
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	c, err := loadCharacter(body)
+	if err != nil {
+		writeLoadError(w, err)
+		return
+	}
+	found, err := s.replace(id, c)
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no character '%s'", id))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("cannot store character: %v", err))
+		return
+	}
+	writeCharacter(w, r, http.StatusOK, c)
+}
+
+// Deletes a stored character.
+func deleteCharacter(w http.ResponseWriter, r *http.Request) {
+	s, ok := charStore(w)
+	if !ok {
+		return
+	}
+	id := mux.Vars(r)["id"]
+	found, err := s.remove(id)
+	if !found {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no character '%s'", id))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("cannot store character: %v", err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Writes the response for a failure to generate a character: 400 for invalid
+// options or unsatisfiable constraints, 503 if the character db is missing
+// and 500 otherwise.
+func writeGenerateError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr sotdlgen.ValidationError
+	var cerr *sotdlgen.ConstraintError
+	switch {
+	case errors.As(err, &verr):
+		writeJSON(w, http.StatusBadRequest, errorBody{"invalid request", verr})
+	case errors.As(err, &cerr):
+		writeJSON(w, http.StatusBadRequest, errorBody{"invalid request",
+			[]sotdlgen.FieldError{{Field: "constraints", Message: cerr.Error()}}})
+	case errors.Is(err, sotdlgen.ErrNoCharDB):
+		writeError(w, http.StatusServiceUnavailable, err)
+	default:
+		log.Errorf("request_id=%s generate: %v", requestID(r), err)
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to generate character: %v", err))
+	}
+}
+
+// Renders the character in the request body, as for a stored character.
+func renderCharacter(w http.ResponseWriter, r *http.Request) {
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+	c, err := loadCharacter(body)
+	if err != nil {
+		writeLoadError(w, err)
+		return
+	}
+	writeCharacter(w, r, http.StatusOK, c)
+}
+
+// Writes the response for a character that cannot be loaded: 503 if the
+// character db is missing and 400 otherwise.
+func writeLoadError(w http.ResponseWriter, err error) {
+	var verr sotdlgen.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeJSON(w, http.StatusBadRequest, errorBody{"invalid character", verr})
+	case errors.Is(err, sotdlgen.ErrNoCharDB):
+		writeError(w, http.StatusServiceUnavailable, err)
+	default:
+		writeError(w, http.StatusBadRequest, err)
+	}
+}
+
+// Largest request body accepted.
+const maxBodyBytes = 1 << 20
+
+// readBody reads the request body, or writes a 413 or 400 response if it is
+// too large or cannot be read.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, http.StatusRequestEntityTooLarge,
+			fmt.Errorf("request body larger than %d bytes", maxBodyBytes))
+		return nil, false
+	case err != nil:
+		writeError(w, http.StatusBadRequest, fmt.Errorf("cannot read request body: %v", err))
+		return nil, false
+	}
+	return body, true
+}
+
+// loadCharacter loads and validates a character against the character db.
+// The body is read beforehand, so that a slow client does not hold the lock.
+func loadCharacter(raw []byte) (sotdlgen.Character, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return sotdlgen.LoadCharacter(bytes.NewReader(raw))
+}
@@ -1,12 +1,19 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"log"
+	stdlog "log"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/docopt/docopt-go"
 	"github.com/gorilla/mux"
@@ -15,50 +22,247 @@ import (
 
 var mutex sync.Mutex
 
-var usage = `M6IK Character Generation Service
+var usage = `SotDL Character Generation Service
 
-Usage: m6ikserv [options]
+Usage: sotdlserv [options]
 
 Options:
-  --port PORT	  The listening port. [default: 8080]
+  --port PORT             The listening port. [default: 8080]
+  --template-dir DIR      Directory of additional sheet templates.
+  --store FILE            Keep generated characters in FILE, a JSON file
+                          rewritten on every change; meant for rosters of at
+                          most a few thousand characters.
+  --read-timeout DUR      Limit on reading a request. [default: 10s]
+  --write-timeout DUR     Limit on writing a response. [default: 60s]
+  --idle-timeout DUR      Limit on keeping an idle connection open.
+                          [default: 120s]
+  --shutdown-timeout DUR  Limit on finishing in-flight requests on SIGINT or
+                          SIGTERM. [default: 30s]
   -h --help
   --version
 `
 
 var cmdOpts struct {
-	Port string `docopt:"--port"`
+	Port        string `docopt:"--port"`
+	TemplateDir string `docopt:"--template-dir"`
+	Store       string `docopt:"--store"`
+	// Server timeouts, as durations such as "30s".
+	ReadTimeout     string `docopt:"--read-timeout"`
+	WriteTimeout    string `docopt:"--write-timeout"`
+	IdleTimeout     string `docopt:"--idle-timeout"`
+	ShutdownTimeout string `docopt:"--shutdown-timeout"`
 }
 
 func generate(w http.ResponseWriter, r *http.Request) {
-	charOpts := sotdlgen.Opts{
-		Name:       r.URL.Query().Get("name"),
-		Gender:     r.URL.Query().Get("gender"),
-		Level:      r.URL.Query().Get("level"),
-		Ancestry:   r.URL.Query().Get("ancestry"),
-		ExpertPath: r.URL.Query().Get("expert-path"),
-		MasterPath: r.URL.Query().Get("master-path"),
-		NovicePath: r.URL.Query().Get("novice-path"),
-		Seed:       r.URL.Query().Get("seed"),
-		LogLevel:   "ERROR",
-	}
-	mutex.Lock()
-	c, err := sotdlgen.NewCharacter(charOpts)
+	c, err := newCharacter(r)
 	if err != nil {
-		fmt.Println("An error occurred:", err)
+		writeGenerateError(w, r, err)
+		return
 	}
-	mutex.Unlock()
-	json.NewEncoder(w).Encode(c)
+	writeCharacter(w, r, http.StatusOK, c)
+}
+
+// Renders a character as a self-contained HTML sheet.
+func sheet(w http.ResponseWriter, r *http.Request) {
+	c, err := newCharacter(r)
+	if err != nil {
+		writeGenerateError(w, r, err)
+		return
+	}
+	rend, _ := sotdlgen.GetRenderer("html")
+	w.Header().Set("Content-Type", rend.ContentType())
+	if err := rend.Render(w, c); err != nil {
+		log.Errorf("request_id=%s render: %v", requestID(r), err)
+	}
+}
+
+// Generates a character from the query parameters. Lists are
+// comma-separated, and each where parameter adds a constraint.
+func newCharacter(r *http.Request) (sotdlgen.Character, error) {
+	q := r.URL.Query()
+	req := sotdlgen.GenerateRequest{
+		Name:        q.Get("name"),
+		Ethnicities: splitList(q.Get("ethnicity")),
+		NameGen:     q.Get("name-gen"),
+		Gender:      q.Get("gender"),
+		Genders:     splitList(q.Get("genders")),
+		Pronouns:    q.Get("pronouns"),
+		Ancestry:    q.Get("ancestry"),
+		ExpertPath:  q.Get("expert-path"),
+		MasterPath:  q.Get("master-path"),
+		NovicePath:  q.Get("novice-path"),
+		Seed:        q.Get("seed"),
+		Constraints: q["where"],
+	}
+	if s := q.Get("level"); s != "" {
+		level, err := strconv.Atoi(s)
+		if err != nil {
+			return sotdlgen.Character{}, sotdlgen.ValidationError{
+				{Field: "level", Message: fmt.Sprintf("not a number: '%s'", s)}}
+		}
+		req.Level = &level
+	}
+	return generateCharacter(req)
+}
+
+// Validates the request and generates a character from it, counting it for
+// the metrics.
+func generateCharacter(req sotdlgen.GenerateRequest) (sotdlgen.Character, error) {
+	if err := req.Validate(); err != nil {
+		return sotdlgen.Character{}, err
+	}
+	c, err := func() (sotdlgen.Character, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return sotdlgen.NewCharacter(req.Opts())
+	}()
+	if err == nil {
+		stats.observeCharacter(c)
+	}
+	return c, err
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// Serves the OpenAPI document describing the service.
+func openAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(sotdlgen.OpenAPISpec())
+}
+
+// Picks a renderer from the template parameter, the format parameter, then
+// the Accept header, and defaults to JSON. Templates are restricted to the
+// bundled ones and those in the template directory.
+func renderer(r *http.Request) (sotdlgen.Renderer, error) {
+	if name := r.URL.Query().Get("template"); name != "" {
+		if cmdOpts.TemplateDir != "" {
+			fn := filepath.Join(cmdOpts.TemplateDir, filepath.Base(name))
+			if _, err := os.Stat(fn); err == nil {
+				return sotdlgen.NewTemplateRenderer(fn)
+			}
+		}
+		for _, t := range sotdlgen.BundledTemplates() {
+			if t == name {
+				return sotdlgen.NewTemplateRenderer(name)
+			}
+		}
+		return nil, fmt.Errorf("unknown template '%s'", name)
+	}
+	if format := r.URL.Query().Get("format"); format != "" {
+		return sotdlgen.GetRenderer(format)
+	}
+	if _, rend, ok := sotdlgen.RendererForAccept(r.Header.Get("Accept")); ok {
+		return rend, nil
+	}
+	return sotdlgen.GetRenderer("json")
+}
+
+func writeCharacter(w http.ResponseWriter, r *http.Request, status int, c sotdlgen.Character) {
+	rend, err := renderer(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeRendered(w, r, status, rend, c)
+}
+
+// Writes the character with a renderer already chosen by renderer(r).
+func writeRendered(w http.ResponseWriter, r *http.Request, status int, rend sotdlgen.Renderer, c sotdlgen.Character) {
+	w.Header().Set("Content-Type", rend.ContentType())
+	w.WriteHeader(status)
+	if err := rend.Render(w, c); err != nil {
+		log.Errorf("request_id=%s render: %v", requestID(r), err)
+	}
+}
+
+// timeouts parses the server timeout options.
+func timeouts() (read, write, idle, shutdown time.Duration, err error) {
+	ds := []*time.Duration{&read, &write, &idle, &shutdown}
+	for i, opt := range []string{cmdOpts.ReadTimeout, cmdOpts.WriteTimeout, cmdOpts.IdleTimeout, cmdOpts.ShutdownTimeout} {
+		if *ds[i], err = time.ParseDuration(opt); err != nil {
+			return
+		}
+	}
+	return
 }
 
 func main() {
 	optFlags, _ := docopt.ParseDoc(usage)
 	optFlags.Bind(&cmdOpts)
+	setupLogging()
+
+	read, write, idle, shutdown, err := timeouts()
+	if err != nil {
+		log.Fatalf("invalid timeout: %v", err)
+	}
+	if cmdOpts.Store != "" {
+		if characters, err = openStore(cmdOpts.Store); err != nil {
+			log.Fatal(err)
+		}
+	}
 
-	fmt.Printf("SotDL Character Generation Service started at <http://localhost:%s>\n", cmdOpts.Port)
+	loadCharDBInBackground()
 
 	runtime.GOMAXPROCS(runtime.NumCPU())
+	router := newRouter()
+	srv := &http.Server{
+		Addr:         ":" + cmdOpts.Port,
+		Handler:      withRequestID(withAccessLog(withMetrics(router, withRecovery(router)))),
+		ReadTimeout:  read,
+		WriteTimeout: write,
+		IdleTimeout:  idle,
+		ErrorLog:     stdlog.New(errorLogWriter{}, "", 0),
+	}
+	done := make(chan struct{})
+	go func() {
+		stop := make(chan os.Signal, 1)
+		signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+		sig := <-stop
+		log.Infof("received %s; shutting down", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdown)
+		defer cancel()
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Errorf("shutdown: %v", err)
+		}
+		close(done)
+	}()
+
+	log.Infof("SotDL Character Generation Service started at <http://localhost:%s>", cmdOpts.Port)
+	if err = srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-done
+	log.Info("stopped")
+}
+
+// newRouter routes the service's endpoints, which are described in the
+// OpenAPI document.
+func newRouter() *mux.Router {
 	router := mux.NewRouter()
 	router.HandleFunc("/", generate).Methods("GET")
 	router.HandleFunc("/generate", generate).Methods("GET")
-	log.Fatal(http.ListenAndServe(":"+cmdOpts.Port, router))
+	router.HandleFunc("/sheet", sheet).Methods("GET")
+	router.HandleFunc("/characters", createCharacter).Methods("POST")
+	router.HandleFunc("/characters", listCharacters).Methods("GET")
+	router.HandleFunc("/characters/{id}", getCharacter).Methods("GET")
+	router.HandleFunc("/characters/{id}", putCharacter).Methods("PUT")
+	router.HandleFunc("/characters/{id}", deleteCharacter).Methods("DELETE")
+	router.HandleFunc("/ancestries", ancestries).Methods("GET")
+	router.HandleFunc("/paths", paths).Methods("GET")
+	router.HandleFunc("/paths/{name}", path).Methods("GET")
+	router.HandleFunc("/names", names).Methods("GET")
+	router.HandleFunc("/genders", genders).Methods("GET")
+	router.HandleFunc("/render", renderCharacter).Methods("POST")
+	router.Handle("/ui", http.RedirectHandler("/ui/", http.StatusMovedPermanently))
+	router.PathPrefix("/ui/").Handler(uiHandler()).Methods("GET")
+	router.HandleFunc("/openapi.json", openAPI).Methods("GET")
+	router.HandleFunc("/healthz", healthz).Methods("GET")
+	router.HandleFunc("/readyz", readyz).Methods("GET")
+	router.HandleFunc("/metrics", metricsHandler).Methods("GET")
+	return router
 }
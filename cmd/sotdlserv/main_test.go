@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+func TestMiddleware(t *testing.T) {
+	h := withRequestID(withAccessLog(withRecovery(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panic("boom")
+		}
+		w.Write([]byte(requestID(r)))
+	}))))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	h.ServeHTTP(w, req)
+	if w.Body.String() != "abc-123" || w.Header().Get("X-Request-ID") != "abc-123" {
+		t.Errorf("Expected the client's request ID, got '%s'.", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "bad id\n")
+	h.ServeHTTP(w, req)
+	if id := w.Header().Get("X-Request-ID"); len(id) != 16 || id != w.Body.String() {
+		t.Errorf("Expected a new request ID, got '%s'.", id)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "internal error") {
+		t.Errorf("Expected a 500 response to a panic, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	m := &metrics{
+		requests:   map[requestKey]int{},
+		latency:    map[string]*histogram{},
+		ancestries: map[string]int{},
+		paths:      map[string]int{},
+	}
+	m.observeRequest("/characters/{id}", "GET", 200, 20*time.Millisecond)
+	m.observeRequest("/characters/{id}", "GET", 200, 2*time.Second)
+	m.observeCharacter(sotdlgen.Character{Ancestry: "Orc", NovicePath: "Warrior", ExpertPath: "Fighter"})
+	b := &strings.Builder{}
+	if err := m.write(b); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`sotdlserv_http_requests_total{route="/characters/{id}",method="GET",code="200"} 2`,
+		`sotdlserv_http_request_duration_seconds_bucket{route="/characters/{id}",le="0.025"} 1`,
+		`sotdlserv_http_request_duration_seconds_bucket{route="/characters/{id}",le="+Inf"} 2`,
+		`sotdlserv_http_request_duration_seconds_count{route="/characters/{id}"} 2`,
+		`sotdlserv_characters_generated_total{ancestry="Orc"} 1`,
+		`sotdlserv_character_paths_generated_total{path="Fighter"} 1`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("Expected metric line '%s'.", line)
+		}
+	}
+}
+
+func TestReadyzWithoutLock(t *testing.T) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	done := make(chan int)
+	go func() {
+		w := httptest.NewRecorder()
+		readyz(w, httptest.NewRequest("GET", "/readyz", nil))
+		done <- w.Code
+	}()
+	select {
+	case code := <-done:
+		if code != http.StatusOK && code != http.StatusServiceUnavailable {
+			t.Errorf("Unexpected readiness status %d.", code)
+		}
+	case <-time.After(time.Second):
+		t.Error("Readiness check waited for the generation lock.")
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	body := `{"name": "` + strings.Repeat("x", maxBodyBytes) + `"}`
+	for _, url := range []string{"/characters", "/render"} {
+		w := httptest.NewRecorder()
+		newRouter().ServeHTTP(w, httptest.NewRequest("POST", url, strings.NewReader(body)))
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("POST %s: expected 413 for an oversized body, got %d.", url, w.Code)
+		}
+	}
+}
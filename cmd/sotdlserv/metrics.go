@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gruevyhat/sotdlgen"
+)
+
+// Upper bounds, in seconds, of the request latency histogram's buckets.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram counts observations into latencyBuckets.
+type histogram struct {
+	buckets []int
+	sum     float64
+	count   int
+}
+
+func (h *histogram) observe(v float64) {
+	if h.buckets == nil {
+		h.buckets = make([]int, len(latencyBuckets))
+	}
+	for i, le := range latencyBuckets {
+		if v <= le {
+			h.buckets[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+type requestKey struct {
+	route  string
+	method string
+	code   int
+}
+
+// metrics counts requests and generated characters for /metrics.
+type metrics struct {
+	mu         sync.Mutex
+	requests   map[requestKey]int
+	latency    map[string]*histogram
+	ancestries map[string]int
+	paths      map[string]int
+}
+
+var stats = &metrics{
+	requests:   map[requestKey]int{},
+	latency:    map[string]*histogram{},
+	ancestries: map[string]int{},
+	paths:      map[string]int{},
+}
+
+func (m *metrics) observeRequest(route, method string, code int, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[requestKey{route, method, code}]++
+	h, ok := m.latency[route]
+	if !ok {
+		h = &histogram{}
+		m.latency[route] = h
+	}
+	h.observe(d.Seconds())
+}
+
+func (m *metrics) observeCharacter(c sotdlgen.Character) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ancestries[c.Ancestry]++
+	for _, p := range c.Paths() {
+		m.paths[p]++
+	}
+}
+
+// labelValue escapes a Prometheus label value.
+func labelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// write writes the metrics in the Prometheus text exposition format.
+func (m *metrics) write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := &strings.Builder{}
+
+	fmt.Fprintln(b, "# HELP sotdlserv_http_requests_total Requests served, by route, method and status code.")
+	fmt.Fprintln(b, "# TYPE sotdlserv_http_requests_total counter")
+	keys := []requestKey{}
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+	for _, k := range keys {
+		fmt.Fprintf(b, "sotdlserv_http_requests_total{route=\"%s\",method=\"%s\",code=\"%d\"} %d\n",
+			labelValue(k.route), k.method, k.code, m.requests[k])
+	}
+
+	fmt.Fprintln(b, "# HELP sotdlserv_http_request_duration_seconds Time taken to serve requests, by route.")
+	fmt.Fprintln(b, "# TYPE sotdlserv_http_request_duration_seconds histogram")
+	routes := []string{}
+	for r := range m.latency {
+		routes = append(routes, r)
+	}
+	sort.Strings(routes)
+	for _, r := range routes {
+		h, route := m.latency[r], labelValue(r)
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(b, "sotdlserv_http_request_duration_seconds_bucket{route=\"%s\",le=\"%s\"} %d\n",
+				route, formatFloat(le), h.buckets[i])
+		}
+		fmt.Fprintf(b, "sotdlserv_http_request_duration_seconds_bucket{route=\"%s\",le=\"+Inf\"} %d\n", route, h.count)
+		fmt.Fprintf(b, "sotdlserv_http_request_duration_seconds_sum{route=\"%s\"} %s\n", route, formatFloat(h.sum))
+		fmt.Fprintf(b, "sotdlserv_http_request_duration_seconds_count{route=\"%s\"} %d\n", route, h.count)
+	}
+
+	fmt.Fprintln(b, "# HELP sotdlserv_characters_generated_total Characters generated, by ancestry.")
+	fmt.Fprintln(b, "# TYPE sotdlserv_characters_generated_total counter")
+	for _, a := range sortedKeys(m.ancestries) {
+		fmt.Fprintf(b, "sotdlserv_characters_generated_total{ancestry=\"%s\"} %d\n", labelValue(a), m.ancestries[a])
+	}
+	fmt.Fprintln(b, "# HELP sotdlserv_character_paths_generated_total Characters generated, by novice, expert or master path.")
+	fmt.Fprintln(b, "# TYPE sotdlserv_character_paths_generated_total counter")
+	for _, p := range sortedKeys(m.paths) {
+		fmt.Fprintf(b, "sotdlserv_character_paths_generated_total{path=\"%s\"} %d\n", labelValue(p), m.paths[p])
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// withMetrics counts requests and their latency by the router's route
+// templates, so that, e.g., every /characters/{id} is one route.
+func withMetrics(router *mux.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		route := "unmatched"
+		var match mux.RouteMatch
+		if router.Match(r, &match) && match.Route != nil {
+			if t, err := match.Route.GetPathTemplate(); err == nil {
+				route = t
+			}
+		}
+		stats.observeRequest(route, r.Method, sw.status, time.Since(start))
+	})
+}
+
+// Serves the metrics in the Prometheus text format.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := stats.write(w); err != nil {
+		log.Errorf("request_id=%s metrics: %v", requestID(r), err)
+	}
+}
+
+// status is the JSON body of the health and readiness checks.
+type status struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Reports that the service is up.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, status{Status: "ok"})
+}
+
+// Reports whether the service can generate characters, i.e., whether the
+// character db is loaded. It answers at once, even while characters are
+// being generated: if the db is not loaded, it starts loading it and
+// reports the last failure, if any.
+func readyz(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&dbStatus.loaded) == 0 {
+		loadCharDBInBackground()
+		msg, _ := dbStatus.err.Load().(string)
+		if msg == "" {
+			msg = "character db not loaded yet"
+		}
+		writeJSON(w, http.StatusServiceUnavailable, status{"unavailable", msg})
+		return
+	}
+	writeJSON(w, http.StatusOK, status{Status: "ready"})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/op/go-logging"
+)
+
+var log = logging.MustGetLogger("sotdlserv")
+
+// setupLogging logs to stderr at INFO for the service, regardless of the
+// level the generator sets for itself.
+func setupLogging() {
+	backend := logging.NewLogBackend(os.Stderr, "", 0)
+	format := logging.MustStringFormatter(`%{time:2006-01-02T15:04:05.000Z07:00} %{level} %{module}: %{message}`)
+	logging.SetBackend(logging.NewBackendFormatter(backend, format))
+	logging.SetLevel(logging.ERROR, "")
+	logging.SetLevel(logging.INFO, "sotdlserv")
+}
+
+// errorLogWriter passes the HTTP server's own errors to the log.
+type errorLogWriter struct{}
+
+func (errorLogWriter) Write(p []byte) (int, error) {
+	log.Warning(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
+
+type contextKey int
+
+const requestIDKey contextKey = 0
+
+// Longest request ID accepted from a client.
+const maxRequestIDLen = 128
+
+// requestID returns the ID of the request, if it has one.
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey).(string)
+	return id
+}
+
+// validRequestID reports whether a client's request ID is short and
+// printable enough to log.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// withRequestID tags each request with the client's X-Request-ID, or a new
+// random one, and echoes it in the response.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			b := make([]byte, 8)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+// statusWriter records the status and size of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	if sw.status == 0 {
+		sw.status = status
+	}
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Write(b []byte) (int, error) {
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+	n, err := sw.ResponseWriter.Write(b)
+	sw.bytes += n
+	return n, err
+}
+
+// withAccessLog logs each request as key=value pairs once it is served.
+func withAccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+		log.Infof("request_id=%s remote=%s method=%s path=%q status=%d bytes=%d duration=%s",
+			requestID(r), r.RemoteAddr, r.Method, r.URL.Path, sw.status, sw.bytes, time.Since(start))
+	})
+}
+
+// withRecovery turns a panicking handler into a 500 response, logging the
+// stack. It must be wrapped directly by a middleware that passes it a
+// statusWriter, i.e., withMetrics or withAccessLog, which tells it whether
+// the response has already started.
+func withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			log.Errorf("request_id=%s panic=%q\n%s", requestID(r), fmt.Sprint(p), debug.Stack())
+			if sw, ok := w.(*statusWriter); !ok || sw.status == 0 {
+				writeError(w, http.StatusInternalServerError, fmt.Errorf("internal error"))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
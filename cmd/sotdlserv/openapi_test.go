@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/gruevyhat/sotdlgen"
+)
+
+// spec is the part of the OpenAPI document checked by the tests.
+type spec struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+func loadSpec(t *testing.T) spec {
+	var s spec
+	if err := json.Unmarshal(sotdlgen.OpenAPISpec(), &s); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// jsonFields lists the JSON names of a struct type's exported fields,
+// including those of embedded structs.
+func jsonFields(t reflect.Type) []string {
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			names = append(names, jsonFields(f.Type)...)
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// refs collects the $ref values in a decoded JSON document.
+func refs(v interface{}, found map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			if s, ok := e.(string); ok && k == "$ref" {
+				found[s] = true
+			}
+			refs(e, found)
+		}
+	case []interface{}:
+		for _, e := range v {
+			refs(e, found)
+		}
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	doc := loadSpec(t)
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("Expected an OpenAPI 3 document, got version '%s'.", doc.OpenAPI)
+	}
+	if len(doc.Paths) == 0 {
+		t.Error("No paths documented.")
+	}
+	var raw map[string]interface{}
+	json.Unmarshal(sotdlgen.OpenAPISpec(), &raw)
+	found := map[string]bool{}
+	refs(raw, found)
+	for ref := range found {
+		parts := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+		var v interface{} = raw
+		for _, p := range parts {
+			m, _ := v.(map[string]interface{})
+			v = m[p]
+		}
+		if v == nil {
+			t.Errorf("Unresolved reference '%s'.", ref)
+		}
+	}
+	// The schemas of the library's types and the server's are checked
+	// together, here, since the server is what serves the document.
+	types := map[string]interface{}{
+		"Character":       sotdlgen.Character{},
+		"Pronouns":        sotdlgen.Pronouns{},
+		"Attributes":      sotdlgen.Attributes{},
+		"Spell":           sotdlgen.Spell{},
+		"Weapon":          sotdlgen.Weapon{},
+		"Armor":           sotdlgen.Armor{},
+		"GenerateRequest": sotdlgen.GenerateRequest{},
+		"FieldError":      sotdlgen.FieldError{},
+		"PathInfo":        sotdlgen.PathInfo{},
+		"Prereq":          sotdlgen.Prereq{},
+		"Level":           sotdlgen.Level{},
+		"NameList":        sotdlgen.NameList{},
+		"Error":           errorBody{},
+		"StoredCharacter": storedCharacter{},
+		"PathDetail":      pathDetail{},
+		"Status":          status{},
+	}
+	for name, v := range types {
+		schema, ok := doc.Components.Schemas[name]
+		if !ok {
+			t.Errorf("No schema for %s.", name)
+			continue
+		}
+		props := []string{}
+		for p := range schema.Properties {
+			props = append(props, p)
+		}
+		sort.Strings(props)
+		if fields := jsonFields(reflect.TypeOf(v)); !reflect.DeepEqual(props, fields) {
+			t.Errorf("Schema %s has properties %v, but the type has fields %v.", name, props, fields)
+		}
+	}
+}
+
+func TestRoutesDocumented(t *testing.T) {
+	s := loadSpec(t)
+	documented := []string{}
+	for p, ops := range s.Paths {
+		for method := range ops {
+			if method != "parameters" {
+				documented = append(documented, strings.ToUpper(method)+" "+p)
+			}
+		}
+	}
+	routed := []string{}
+	newRouter().Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		p, _ := route.GetPathTemplate()
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Redirects answer any method and are not documented.
+			return nil
+		}
+		for _, m := range methods {
+			routed = append(routed, m+" "+p)
+		}
+		return nil
+	})
+	sort.Strings(documented)
+	sort.Strings(routed)
+	if !reflect.DeepEqual(documented, routed) {
+		t.Errorf("Documented endpoints %v differ from routed endpoints %v.", documented, routed)
+	}
+}
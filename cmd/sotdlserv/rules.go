@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+	"github.com/gruevyhat/sotdlgen"
+)
+
+// errorBody is the JSON body of an error response. Details lists the
+// invalid fields of a bad request.
+type errorBody struct {
+	Error   string                `json:"error"`
+	Details []sotdlgen.FieldError `json:"details,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Errorf("encode: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorBody{Error: err.Error()})
+}
+
+// charDB returns the loaded character db, or writes a 503 response.
+func charDB(w http.ResponseWriter) (*sotdlgen.CharDB, bool) {
+	db, err := getCharDB()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return nil, false
+	}
+	return db, true
+}
+
+// dbStatus records whether the character db has loaded, or why it last
+// failed to, so that the readiness check need not wait for the generation
+// lock.
+var dbStatus struct {
+	loaded  int32
+	loading int32
+	err     atomic.Value // string
+}
+
+// getCharDB loads the character db if it is not already loaded.
+func getCharDB() (*sotdlgen.CharDB, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	db, err := sotdlgen.GetCharDB()
+	if err != nil {
+		dbStatus.err.Store(err.Error())
+	} else {
+		atomic.StoreInt32(&dbStatus.loaded, 1)
+	}
+	return db, err
+}
+
+// loadCharDBInBackground loads the character db without waiting for it,
+// unless it is loaded or already loading.
+func loadCharDBInBackground() {
+	if atomic.LoadInt32(&dbStatus.loaded) == 1 || !atomic.CompareAndSwapInt32(&dbStatus.loading, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&dbStatus.loading, 0)
+		getCharDB()
+	}()
+}
+
+// Lists the db's paths with the given names.
+func pathInfos(db *sotdlgen.CharDB, names []string) []sotdlgen.PathInfo {
+	infos := []sotdlgen.PathInfo{}
+	for _, name := range names {
+		if info, ok := db.PathInfo(name); ok {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// Lists the ancestries in the character db.
+func ancestries(w http.ResponseWriter, r *http.Request) {
+	db, ok := charDB(w)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, pathInfos(db, sotdlgen.Ancestries()))
+}
+
+// Lists the paths in the character db, optionally of a single tier.
+func paths(w http.ResponseWriter, r *http.Request) {
+	names, err := sotdlgen.PathsByTier(r.URL.Query().Get("tier"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	db, ok := charDB(w)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, pathInfos(db, names))
+}
+
+// pathDetail is an ancestry or path together with its levels.
+type pathDetail struct {
+	sotdlgen.PathInfo
+	Details sotdlgen.Levels `json:"details"`
+}
+
+// Shows an ancestry or path, level by level.
+func path(w http.ResponseWriter, r *http.Request) {
+	db, ok := charDB(w)
+	if !ok {
+		return
+	}
+	info, ok := db.PathInfo(mux.Vars(r)["name"])
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown path '%s'", mux.Vars(r)["name"]))
+		return
+	}
+	writeJSON(w, http.StatusOK, pathDetail{info, db.Paths[info.Name]})
+}
+
+// Lists the name lists, optionally for a single ancestry, ethnicity or type.
+func names(w http.ResponseWriter, r *http.Request) {
+	db, ok := charDB(w)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	lists := []sotdlgen.NameList{}
+	for _, nl := range db.NameLists(q.Get("ancestry")) {
+		if e := q.Get("ethnicity"); e != "" && !strings.EqualFold(nl.Ethnicity, e) {
+			continue
+		}
+		if t := q.Get("type"); t != "" && !strings.EqualFold(nl.Type, t) {
+			continue
+		}
+		lists = append(lists, nl)
+	}
+	writeJSON(w, http.StatusOK, lists)
+}
+
+// Lists the default genders.
+func genders(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, sotdlgen.Genders())
+}
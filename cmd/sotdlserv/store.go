@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+// storedCharacter is a character in the store, summarized for listing.
+type storedCharacter struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Ancestry  string          `json:"ancestry"`
+	Level     int             `json:"level"`
+	Paths     []string        `json:"paths"`
+	Character json.RawMessage `json:"character,omitempty"`
+}
+
+// store keeps characters in a JSON file, which is held in memory and
+// rewritten whole on every change. That suits a group's roster of hundreds
+// of characters, not tens of thousands.
+type store struct {
+	mu   sync.Mutex
+	file string
+	data struct {
+		NextID     int               `json:"next_id"`
+		Characters []storedCharacter `json:"characters"`
+	}
+	// index maps IDs to positions in data.Characters.
+	index map[string]int
+}
+
+// openStore opens the store in the given file, creating it if needed.
+func openStore(file string) (*store, error) {
+	s := &store{file: file, index: map[string]int{}}
+	s.data.NextID = 1
+	s.data.Characters = []storedCharacter{}
+	raw, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return s, s.save()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("cannot read store %s: %v", file, err)
+	}
+	s.reindex()
+	return s, nil
+}
+
+// reindex rebuilds the index after characters are loaded or removed.
+func (s *store) reindex() {
+	s.index = map[string]int{}
+	for i, sc := range s.data.Characters {
+		s.index[sc.ID] = i
+	}
+}
+
+// save writes the store to a temporary file and moves it into place. Both
+// the file and, after the rename, its directory are synced, so a change
+// that was reported saved survives a crash or power loss.
+func (s *store) save() error {
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.file)
+	tmp, err := ioutil.TempFile(dir, ".sotdlserv-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(raw); err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), s.file); err != nil {
+		return err
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func newStoredCharacter(id string, c sotdlgen.Character) (storedCharacter, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return storedCharacter{}, err
+	}
+	return storedCharacter{id, c.Name, c.Ancestry, c.Level, c.Paths(), raw}, nil
+}
+
+func (s *store) find(id string) int {
+	if i, ok := s.index[id]; ok {
+		return i
+	}
+	return -1
+}
+
+// add stores a new character and returns its ID.
+func (s *store) add(c sotdlgen.Character) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := strconv.Itoa(s.data.NextID)
+	sc, err := newStoredCharacter(id, c)
+	if err != nil {
+		return "", err
+	}
+	s.data.NextID++
+	s.data.Characters = append(s.data.Characters, sc)
+	if err = s.save(); err != nil {
+		s.data.NextID--
+		s.data.Characters = s.data.Characters[:len(s.data.Characters)-1]
+		return "", err
+	}
+	s.index[id] = len(s.data.Characters) - 1
+	return id, nil
+}
+
+// get returns the saved JSON of the character with the given ID.
+func (s *store) get(id string) (json.RawMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if i := s.find(id); i >= 0 {
+		return s.data.Characters[i].Character, true
+	}
+	return nil, false
+}
+
+// replace overwrites the character with the given ID, returning false if
+// there is none.
+func (s *store) replace(id string, c sotdlgen.Character) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.find(id)
+	if i < 0 {
+		return false, nil
+	}
+	sc, err := newStoredCharacter(id, c)
+	if err != nil {
+		return true, err
+	}
+	old := s.data.Characters[i]
+	s.data.Characters[i] = sc
+	if err = s.save(); err != nil {
+		s.data.Characters[i] = old
+		return true, err
+	}
+	return true, nil
+}
+
+// remove deletes the character with the given ID, returning false if there
+// is none.
+func (s *store) remove(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.find(id)
+	if i < 0 {
+		return false, nil
+	}
+	saved := s.data.Characters
+	s.data.Characters = append(append([]storedCharacter{}, saved[:i]...), saved[i+1:]...)
+	if err := s.save(); err != nil {
+		s.data.Characters = saved
+		return true, err
+	}
+	s.reindex()
+	return true, nil
+}
+
+// storeFilter selects stored characters by ancestry, path and level. Empty
+// fields and a negative level match every character.
+type storeFilter struct {
+	Ancestry string
+	Path     string
+	Level    int
+}
+
+func (f storeFilter) matches(sc storedCharacter) bool {
+	if f.Ancestry != "" && !strings.EqualFold(f.Ancestry, sc.Ancestry) {
+		return false
+	}
+	if f.Level >= 0 && f.Level != sc.Level {
+		return false
+	}
+	if f.Path == "" {
+		return true
+	}
+	for _, p := range sc.Paths {
+		if strings.EqualFold(f.Path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// list summarizes the stored characters matching the filter, in the order
+// they were added.
+func (s *store) list(f storeFilter) []storedCharacter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	found := []storedCharacter{}
+	for _, sc := range s.data.Characters {
+		if f.matches(sc) {
+			sc.Character = nil
+			found = append(found, sc)
+		}
+	}
+	return found
+}
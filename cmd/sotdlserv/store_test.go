@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruevyhat/sotdlgen"
+)
+
+var roster = []sotdlgen.Character{
+	{Name: "Grub", Ancestry: "Orc", Level: 1, NovicePath: "Warrior"},
+	{Name: "Vesna", Ancestry: "Dwarf", Level: 3, NovicePath: "Warrior", ExpertPath: "Fighter"},
+	{Name: "Tink", Ancestry: "Goblin", Level: 1, NovicePath: "Rogue"},
+}
+
+// newTestStore opens a store in a temporary directory holding the roster.
+func newTestStore(t *testing.T) *store {
+	s, err := openStore(filepath.Join(t.TempDir(), "roster.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range roster {
+		if _, err = s.add(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func storedNames(scs []storedCharacter) string {
+	names := []string{}
+	for _, sc := range scs {
+		names = append(names, sc.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestStoreReopen(t *testing.T) {
+	s := newTestStore(t)
+	if found, err := s.remove("3"); !found || err != nil {
+		t.Fatalf("Cannot remove character 3: %v.", err)
+	}
+	s, err := openStore(s.file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := storedNames(s.list(storeFilter{Level: -1})); names != "Grub,Vesna" {
+		t.Errorf("Expected Grub and Vesna after reopening, got '%s'.", names)
+	}
+	if _, ok := s.get("2"); !ok {
+		t.Error("Character 2 not found after reopening.")
+	}
+	if id, _ := s.add(roster[2]); id != "4" {
+		t.Errorf("Expected IDs to continue at 4, got '%s'.", id)
+	}
+}
+
+func TestStoreFilter(t *testing.T) {
+	s := newTestStore(t)
+	for _, tc := range []struct {
+		f    storeFilter
+		want string
+	}{
+		{storeFilter{Level: -1}, "Grub,Vesna,Tink"},
+		{storeFilter{Ancestry: "orc", Level: -1}, "Grub"},
+		{storeFilter{Path: "warrior", Level: -1}, "Grub,Vesna"},
+		{storeFilter{Path: "Fighter", Level: -1}, "Vesna"},
+		{storeFilter{Level: 1}, "Grub,Tink"},
+		{storeFilter{Path: "Warrior", Level: 1}, "Grub"},
+		{storeFilter{Ancestry: "Elf", Level: -1}, ""},
+	} {
+		if got := storedNames(s.list(tc.f)); got != tc.want {
+			t.Errorf("Filter %+v: expected '%s', got '%s'.", tc.f, tc.want, got)
+		}
+	}
+}
+
+func TestStoreRollback(t *testing.T) {
+	s := newTestStore(t)
+	// Saving fails once the store's directory is gone.
+	if err := os.RemoveAll(filepath.Dir(s.file)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.add(roster[0]); err == nil {
+		t.Error("Expected adding to fail.")
+	}
+	if found, err := s.replace("1", roster[1]); !found || err == nil {
+		t.Error("Expected replacing to fail.")
+	}
+	if found, err := s.remove("2"); !found || err == nil {
+		t.Error("Expected removing to fail.")
+	}
+	if names := storedNames(s.list(storeFilter{Level: -1})); names != "Grub,Vesna,Tink" {
+		t.Errorf("Expected the roster to be unchanged, got '%s'.", names)
+	}
+	if s.data.NextID != 4 {
+		t.Errorf("Expected the next ID to be unchanged, got %d.", s.data.NextID)
+	}
+}
+
+func serve(method, url, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	newRouter().ServeHTTP(w, httptest.NewRequest(method, url, strings.NewReader(body)))
+	return w
+}
+
+func TestStoreHandlers(t *testing.T) {
+	saved := characters
+	defer func() { characters = saved }()
+
+	characters = nil
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		if w := serve(method, "/characters/1", "{}"); w.Code != http.StatusNotImplemented {
+			t.Errorf("%s without storage: expected 501, got %d.", method, w.Code)
+		}
+	}
+	if w := serve("GET", "/characters", ""); w.Code != http.StatusNotImplemented {
+		t.Errorf("Listing without storage: expected 501, got %d.", w.Code)
+	}
+
+	characters = newTestStore(t)
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		if w := serve(method, "/characters/9", "{}"); w.Code != http.StatusNotFound {
+			t.Errorf("%s of a missing character: expected 404, got %d.", method, w.Code)
+		}
+	}
+	if w := serve("DELETE", "/characters/3", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 on deletion, got %d.", w.Code)
+	}
+	if w := serve("GET", "/characters?level=x", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad level, got %d.", w.Code)
+	}
+	w := serve("POST", "/characters?format=bogus", "{}")
+	if w.Code != http.StatusBadRequest || w.Header().Get("Location") != "" {
+		t.Errorf("Expected 400 and no Location for a bad format, got %d.", w.Code)
+	}
+	if names := storedNames(characters.list(storeFilter{Level: -1})); names != "Grub,Vesna" {
+		t.Errorf("Expected nothing stored for a bad format, got '%s'.", names)
+	}
+}
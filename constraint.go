@@ -0,0 +1,520 @@
+// Constraint-based generation.
+
+package sotdlgen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Maximum number of characters generated in search of one satisfying the
+// constraints.
+const maxConstraintAttempts = 1000
+
+// Constraint restricts the characters NewCharacter may generate, e.g.,
+// "ancestry in Dwarf,Orc", "no Clockwork", "expert_path is spellcaster" or
+// "strength >= 12".
+type Constraint struct {
+	Field  string
+	Op     string
+	Values []string
+	text   string
+}
+
+// Constraint operators. The spellcaster operators test whether a path can
+// discover a tradition (or, for master paths, requires one).
+const (
+	opIn             = "in"
+	opNotIn          = "not in"
+	opEq             = "="
+	opNe             = "!="
+	opGe             = ">="
+	opLe             = "<="
+	opGt             = ">"
+	opLt             = "<"
+	opSpellcaster    = "spellcaster"
+	opNotSpellcaster = "not spellcaster"
+)
+
+// Fields that may be constrained, mapped to their permitted values, if
+// restricted. "path" matches any of the character's paths.
+var constraintFields = map[string][]string{
+	"name":        nil,
+	"gender":      nil,
+	"ancestry":    ancestries,
+	"novice_path": novicePaths,
+	"expert_path": expertPaths,
+	"master_path": masterPaths,
+	"path":        nil,
+}
+
+// Numeric fields that may be constrained.
+var numericFields = []string{
+	"level", "strength", "agility", "intellect", "will", "perception", "defense",
+	"health", "healing_rate", "speed", "power", "insanity", "corruption",
+}
+
+var (
+	reSymbolicConstraint = regexp.MustCompile(`^([A-Za-z][A-Za-z_ ]*?)\s*(>=|<=|!=|=|>|<|≥|≤)\s*(.+)$`)
+	reWordConstraint     = regexp.MustCompile(`(?i)^([A-Za-z][A-Za-z_ ]*?)\s+(not\s+in|in|is\s+not|is|casts)\s+(.+)$`)
+	reNoConstraint       = regexp.MustCompile(`(?i)^no\s+(.+)$`)
+)
+
+// ParseConstraint parses a constraint of one of the forms
+//
+//	<field> in <value>,<value>...
+//	<field> not in <value>,<value>...
+//	<field> =|!=|>=|<=|>|< <value>
+//	<field> is [not] spellcaster
+//	no <ancestry or path>
+//
+// where lists may be wrapped in braces.
+func ParseConstraint(s string) (k Constraint, err error) {
+	k.text = strings.TrimSpace(s)
+	var field, op, rest string
+	if m := reSymbolicConstraint.FindStringSubmatch(k.text); m != nil {
+		field, op, rest = m[1], m[2], m[3]
+	} else if m := reWordConstraint.FindStringSubmatch(k.text); m != nil {
+		field, op, rest = m[1], strings.Join(strings.Fields(strings.ToLower(m[2])), " "), m[3]
+	} else if m := reNoConstraint.FindStringSubmatch(k.text); m != nil {
+		for _, f := range []string{"ancestry", "novice_path", "expert_path", "master_path"} {
+			if v, ok := canonical(constraintFields[f], m[1]); ok {
+				k.Field, k.Op, k.Values = f, opNotIn, []string{v}
+				return k, nil
+			}
+		}
+		return k, fmt.Errorf("'%s': unknown ancestry or path '%s'", k.text, m[1])
+	} else {
+		return k, fmt.Errorf("'%s': expected e.g. 'ancestry in Dwarf,Orc' or 'strength >= 12'", k.text)
+	}
+	k.Field = strings.Replace(strings.ToLower(strings.TrimSpace(field)), " ", "_", -1)
+	rest = strings.TrimSpace(rest)
+	switch op {
+	case "≥":
+		op = opGe
+	case "≤":
+		op = opLe
+	case "casts":
+		if strings.ToLower(rest) != "spells" {
+			return k, fmt.Errorf("'%s': expected '%s casts spells'", k.text, k.Field)
+		}
+		op, rest = "is", opSpellcaster
+	}
+	if op == "is" || op == "is not" {
+		if strings.ToLower(rest) == opSpellcaster {
+			op = strings.TrimPrefix(op+" ", "is ") + opSpellcaster
+		} else if op == "is" {
+			op = opEq
+		} else {
+			op = opNe
+		}
+	}
+	k.Op = op
+	k.Values = splitList(strings.Trim(rest, "{}"))
+	if len(k.Values) == 0 {
+		return k, fmt.Errorf("'%s': no values given", k.text)
+	}
+
+	if stringIn(numericFields, k.Field) {
+		if k.Op == opSpellcaster || k.Op == opNotSpellcaster {
+			return k, fmt.Errorf("'%s': only paths can be spellcasters", k.text)
+		}
+		for _, v := range k.Values {
+			if _, err := strconv.Atoi(v); err != nil {
+				return k, fmt.Errorf("'%s': '%s' is not a number", k.text, v)
+			}
+		}
+		return k, nil
+	}
+	allowed, ok := constraintFields[k.Field]
+	if !ok {
+		return k, fmt.Errorf("'%s': unknown field '%s'", k.text, k.Field)
+	}
+	switch k.Op {
+	case opEq:
+		k.Op = opIn
+	case opNe:
+		k.Op = opNotIn
+	case opIn, opNotIn:
+	case opSpellcaster, opNotSpellcaster:
+		if !strings.HasSuffix(k.Field, "path") {
+			return k, fmt.Errorf("'%s': only paths can be spellcasters", k.text)
+		}
+		k.Values = nil
+		return k, nil
+	default:
+		return k, fmt.Errorf("'%s': '%s' only applies to numbers", k.text, k.Op)
+	}
+	if k.Field == "path" {
+		allowed = append(append(append([]string{}, novicePaths...), expertPaths...), masterPaths...)
+	}
+	if allowed != nil {
+		for i, v := range k.Values {
+			if k.Values[i], ok = canonical(allowed, v); !ok {
+				return k, fmt.Errorf("'%s': unknown %s '%s'", k.text, strings.Replace(k.Field, "_", " ", -1), v)
+			}
+		}
+	}
+	return k, nil
+}
+
+// ParseConstraints parses a list of constraints, reporting all that are
+// invalid.
+func ParseConstraints(exprs []string) ([]Constraint, error) {
+	ks := []Constraint{}
+	errs := ValidationError{}
+	for _, s := range exprs {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		k, err := ParseConstraint(s)
+		if err != nil {
+			errs = append(errs, FieldError{"constraints", err.Error()})
+			continue
+		}
+		ks = append(ks, k)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return ks, nil
+}
+
+// canonical returns the entry of the list equal to s, ignoring case.
+func canonical(list []string, s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return v, true
+		}
+	}
+	return s, false
+}
+
+// String returns the constraint as written.
+func (k Constraint) String() string {
+	if k.text != "" {
+		return k.text
+	}
+	return strings.TrimSpace(k.Field + " " + k.Op + " " + strings.Join(k.Values, ","))
+}
+
+// Satisfied reports whether the character satisfies the constraint.
+func (k Constraint) Satisfied(c Character) bool {
+	var values []string
+	switch k.Field {
+	case "name":
+		values = []string{c.Name}
+	case "gender":
+		values = []string{c.Gender}
+	case "ancestry":
+		values = []string{c.Ancestry}
+	case "novice_path":
+		values = []string{c.NovicePath}
+	case "expert_path":
+		values = []string{c.ExpertPath}
+	case "master_path":
+		values = []string{c.MasterPath}
+	case "path":
+		values = c.Paths()
+	default:
+		return k.allows(strconv.Itoa(c.numericField(k.Field)))
+	}
+	for i := 0; i < len(values); i++ {
+		if values[i] == "" {
+			values = append(values[:i], values[i+1:]...)
+			i--
+		}
+	}
+	return k.matches(values)
+}
+
+// allows reports whether the constraint admits a field with the given value.
+func (k Constraint) allows(value string) bool {
+	return k.matches([]string{value})
+}
+
+// matches reports whether the constraint admits a field with the given
+// values, any of which may match.
+func (k Constraint) matches(values []string) bool {
+	anyOf := func(pred func(v string) bool) bool {
+		for _, v := range values {
+			if pred(v) {
+				return true
+			}
+		}
+		return false
+	}
+	in := func(v string) bool {
+		for _, want := range k.Values {
+			if strings.EqualFold(v, want) {
+				return true
+			}
+		}
+		return false
+	}
+	switch k.Op {
+	case opIn:
+		return anyOf(in)
+	case opNotIn:
+		return !anyOf(in)
+	case opSpellcaster:
+		return anyOf(castsSpells)
+	case opNotSpellcaster:
+		return !anyOf(castsSpells)
+	}
+	return anyOf(func(v string) bool {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return false
+		}
+		want, _ := strconv.Atoi(k.Values[0])
+		switch k.Op {
+		case opEq:
+			return in(v)
+		case opNe:
+			return !in(v)
+		case opGe:
+			return n >= want
+		case opLe:
+			return n <= want
+		case opGt:
+			return n > want
+		case opLt:
+			return n < want
+		}
+		return false
+	})
+}
+
+// castsSpells reports whether the path can discover a tradition, or, for a
+// master path, requires one.
+func castsSpells(path string) bool {
+	return len(db.Traditions[path]) > 0 || len(pathTraditions[path]) > 0 ||
+		len(masterPathPrereqs[path].Traditions) > 0
+}
+
+// numericField returns the named level, attribute or characteristic.
+func (c Character) numericField(field string) int {
+	a := c.Attributes
+	return map[string]int{
+		"level":        c.Level,
+		"strength":     a.Strength,
+		"agility":      a.Agility,
+		"intellect":    a.Intellect,
+		"will":         a.Will,
+		"perception":   a.Perception,
+		"defense":      a.Defense,
+		"health":       a.Health,
+		"healing_rate": a.HealingRate,
+		"speed":        a.Speed,
+		"power":        a.Power,
+		"insanity":     a.Insanity,
+		"corruption":   a.Corruption,
+	}[field]
+}
+
+// ConstraintError explains why no character satisfying the constraints
+// could be generated.
+type ConstraintError struct {
+	// Reason, if set, names a constraint that can never be satisfied.
+	Reason string
+	// Attempts is the number of characters generated and rejected.
+	Attempts int
+	// Failures counts the rejected characters failing each constraint.
+	Failures map[string]int
+}
+
+func (e *ConstraintError) Error() string {
+	if e.Reason != "" {
+		return "unsatisfiable constraints: " + e.Reason
+	}
+	keys := []string{}
+	for k := range e.Failures {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if e.Failures[keys[i]] != e.Failures[keys[j]] {
+			return e.Failures[keys[i]] > e.Failures[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	msgs := []string{}
+	for _, k := range keys {
+		msgs = append(msgs, fmt.Sprintf("'%s' failed %d times", k, e.Failures[k]))
+	}
+	return fmt.Sprintf("no character satisfied the constraints in %d attempts: %s",
+		e.Attempts, strings.Join(msgs, ", "))
+}
+
+// optSlot returns the option that pins the given field, if any.
+func (o *Opts) optSlot(field string) *string {
+	switch field {
+	case "gender":
+		return &o.Gender
+	case "ancestry":
+		return &o.Ancestry
+	case "novice_path":
+		return &o.NovicePath
+	case "expert_path":
+		return &o.ExpertPath
+	case "master_path":
+		return &o.MasterPath
+	case "level":
+		return &o.Level
+	}
+	return nil
+}
+
+// newConstrainedCharacter generates characters until one satisfies the
+// constraints. Unpinned ancestries, paths, genders and levels are drawn only
+// from the values the constraints allow, and a constraint requiring a master
+// path also requires level 7 or more; the rest are checked after
+// generation. Each attempt is seeded from opts.Seed, so the search is
+// reproducible: the character carries opts.Seed, or the seed drawn in its
+// place, which regenerates it only together with the same constraints.
+func newConstrainedCharacter(opts Opts) (c Character, err error) {
+	ks, err := ParseConstraints(opts.Constraints)
+	if err != nil {
+		return c, err
+	}
+	// Constraints on the master path imply one, and so a minimum level,
+	// unless they admit its absence.
+	implied := map[string][]Constraint{}
+	for _, k := range ks {
+		if k.Field == "master_path" && !k.matches(nil) {
+			implied["level"] = append(implied["level"],
+				Constraint{Field: "level", Op: opGe, Values: []string{strconv.Itoa(masterLevel)}, text: k.String()})
+		}
+	}
+	levels := []string{}
+	for i := 0; i <= maxLevel; i++ {
+		levels = append(levels, strconv.Itoa(i))
+	}
+	slots := []struct {
+		field    string
+		universe []string
+	}{
+		{"ancestry", ancestries}, {"novice_path", novicePaths}, {"expert_path", expertPaths},
+		{"master_path", masterPaths}, {"gender", genderChoices(opts.Genders)}, {"level", levels},
+	}
+	narrowed := map[string][]string{}
+	for _, s := range slots {
+		fks := []string{}
+		sks := implied[s.field]
+		for _, k := range ks {
+			if k.Field == s.field {
+				fks = append(fks, k.String())
+				sks = append(sks, k)
+			}
+		}
+		for _, k := range implied[s.field] {
+			fks = append(fks, k.String())
+		}
+		if len(sks) == 0 {
+			continue
+		}
+		if v := *opts.optSlot(s.field); v != "" {
+			// A pinned level must still admit the implied master path.
+			for _, k := range implied[s.field] {
+				if !k.allows(v) {
+					return c, &ConstraintError{Reason: fmt.Sprintf("'%s' requires %s %s %s, got %s",
+						k.String(), s.field, k.Op, k.Values[0], v)}
+				}
+			}
+			continue
+		}
+		allowed := []string{}
+		for _, v := range s.universe {
+			ok := true
+			for _, k := range sks {
+				ok = ok && k.allows(v)
+			}
+			if ok {
+				allowed = append(allowed, v)
+			}
+		}
+		if len(allowed) == 0 {
+			return c, &ConstraintError{Reason: fmt.Sprintf("no %s satisfies '%s'",
+				strings.Replace(s.field, "_", " ", -1), strings.Join(fks, "' and '"))}
+		}
+		narrowed[s.field] = allowed
+	}
+
+	master := opts.Seed
+	if master == "" {
+		master = newMasterSeed()
+	}
+	cerr := &ConstraintError{Failures: map[string]int{}}
+	for i := 0; i < maxConstraintAttempts; i++ {
+		o := opts
+		o.Seed = deriveSeed(master, i)
+		if _, err = setSeed(o.Seed); err != nil {
+			return c, err
+		}
+		for _, s := range slots {
+			allowed, ok := narrowed[s.field]
+			if !ok {
+				continue
+			}
+			// Master paths depend on the paths before them, so are drawn
+			// during generation, after a path one of them requires.
+			if s.field == "master_path" {
+				o.masterPaths = allowed
+				o.drawPrereqPath(randomChoice(allowed), opts, narrowed)
+			} else {
+				*o.optSlot(s.field) = randomChoice(allowed)
+			}
+		}
+		if c, err = newCharacter(o); err != nil {
+			return c, err
+		}
+		ok := true
+		for _, k := range ks {
+			if !k.Satisfied(c) {
+				cerr.Failures[k.String()]++
+				ok = false
+			}
+		}
+		if ok {
+			c.Seed = master
+			return c, nil
+		}
+		if opts.UsedNames != nil && opts.Name == "" {
+			opts.UsedNames.Remove(c.Name)
+		}
+		cerr.Attempts++
+	}
+	return Character{}, cerr
+}
+
+// drawPrereqPath sets a novice or expert path the master path requires,
+// unless one is set already, from those the options leave open.
+func (o *Opts) drawPrereqPath(master string, opts Opts, narrowed map[string][]string) {
+	pr := db.Prereqs[master]
+	if len(pr.Paths) == 0 || stringIn(pr.Paths, o.NovicePath) || stringIn(pr.Paths, o.ExpertPath) {
+		return
+	}
+	fields, paths := []string{}, []string{}
+	for _, f := range []string{"novice_path", "expert_path"} {
+		if *opts.optSlot(f) != "" {
+			continue
+		}
+		allowed, ok := narrowed[f]
+		if !ok {
+			allowed = constraintFields[f]
+		}
+		for _, p := range pr.Paths {
+			if stringIn(allowed, p) {
+				fields, paths = append(fields, f), append(paths, p)
+			}
+		}
+	}
+	if len(paths) > 0 {
+		i := rng.Intn(len(paths))
+		*o.optSlot(fields[i]) = paths[i]
+	}
+}
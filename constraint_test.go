@@ -0,0 +1,150 @@
+package sotdlgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		in     string
+		field  string
+		op     string
+		values []string
+	}{
+		{"ancestry in {Dwarf, orc}", "ancestry", opIn, []string{"Dwarf", "Orc"}},
+		{"no Clockwork", "ancestry", opNotIn, []string{"Clockwork"}},
+		{"no Wizard", "expert_path", opNotIn, []string{"Wizard"}},
+		{"Expert Path is spellcaster", "expert_path", opSpellcaster, nil},
+		{"master_path is not spellcaster", "master_path", opNotSpellcaster, nil},
+		{"novice_path casts spells", "novice_path", opSpellcaster, nil},
+		{"Strength >= 12", "strength", opGe, []string{"12"}},
+		{"level≤3", "level", opLe, []string{"3"}},
+		{"gender is Female", "gender", opIn, []string{"Female"}},
+		{"ancestry != Human", "ancestry", opNotIn, []string{"Human"}},
+	}
+	for _, tt := range tests {
+		k, err := ParseConstraint(tt.in)
+		if err != nil {
+			t.Errorf("'%s': %v", tt.in, err)
+			continue
+		}
+		if k.Field != tt.field || k.Op != tt.op || strings.Join(k.Values, ",") != strings.Join(tt.values, ",") {
+			t.Errorf("'%s': got %s %s %v.", tt.in, k.Field, k.Op, k.Values)
+		}
+	}
+	for _, in := range []string{"ancestry in Elf", "no Elf", "luck > 3", "strength > lots",
+		"ancestry >= 3", "gender is spellcaster", "hello"} {
+		if _, err := ParseConstraint(in); err == nil {
+			t.Errorf("Expected an error parsing '%s'.", in)
+		}
+	}
+}
+
+func TestConstraintSatisfied(t *testing.T) {
+	c := testCharacter
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"ancestry in Orc,Dwarf", c.Ancestry == "Orc" || c.Ancestry == "Dwarf"},
+		{"strength >= 1", true},
+		{"strength < 1", false},
+		{"path in " + c.NovicePath, true},
+		{"master_path in Bard", false},
+	}
+	for _, tt := range tests {
+		k, _ := ParseConstraint(tt.in)
+		if got := k.Satisfied(c); got != tt.want {
+			t.Errorf("'%s': expected %v, got %v.", tt.in, tt.want, got)
+		}
+	}
+}
+
+func TestNewConstrainedCharacter(t *testing.T) {
+	useTestDB(t)
+	opts := Opts{
+		Seed:     "1575d911f49e59ee",
+		LogLevel: "ERROR",
+		Constraints: []string{
+			"ancestry in Dwarf,Orc", "level >= 3", "expert_path is spellcaster", "strength >= 10",
+		},
+	}
+	for i := 0; i < 10; i++ {
+		opts.Seed = deriveSeed("constraints", i)
+		c, err := NewCharacter(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (c.Ancestry != "Dwarf" && c.Ancestry != "Orc") || c.Level < 3 ||
+			!castsSpells(c.ExpertPath) || c.Attributes.Strength < 10 {
+			t.Errorf("Constraints not satisfied: %s %s level %d, Strength %d.",
+				c.Ancestry, c.ExpertPath, c.Level, c.Attributes.Strength)
+		}
+	}
+	// The seed reproduces the character with the same constraints.
+	for _, seed := range []string{"1575d911f49e59ee", ""} {
+		opts.Seed = seed
+		c, err := NewCharacter(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seed != "" && c.Seed != seed {
+			t.Errorf("Expected seed '%s', got '%s'.", seed, c.Seed)
+		}
+		opts.Seed = c.Seed
+		again, err := NewCharacter(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(again, c) {
+			t.Errorf("Seed '%s' does not reproduce the character.", c.Seed)
+		}
+	}
+	// A master path is narrowed, among those the character qualifies for,
+	// and implies level 7 or more.
+	opts.Constraints = []string{"master_path in Chaplain,Weapon Master"}
+	for i := 0; i < 10; i++ {
+		opts.Seed = deriveSeed("master", i)
+		c, err := NewCharacter(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Level < masterLevel || (c.MasterPath != "Chaplain" && c.MasterPath != "Weapon Master") ||
+			!c.qualifiesFor(c.MasterPath) {
+			t.Errorf("Master path not narrowed: %s %s %s at level %d.",
+				c.NovicePath, c.ExpertPath, c.MasterPath, c.Level)
+		}
+	}
+	opts.Constraints = append(opts.Constraints, "strength > 40")
+	_, err := NewCharacter(opts)
+	if cerr, ok := err.(*ConstraintError); !ok || cerr.Failures[opts.Constraints[0]] != 0 {
+		t.Errorf("Expected only strength to fail, got %v.", err)
+	}
+	opts.Level = "3"
+	_, err = NewCharacter(opts)
+	if cerr, ok := err.(*ConstraintError); !ok || cerr.Reason == "" {
+		t.Errorf("Expected a master path below level 7 to be unsatisfiable, got %v.", err)
+	}
+	opts.Constraints = []string{"no Chaplain"}
+	if c, err := NewCharacter(opts); err != nil || c.Level != 3 {
+		t.Errorf("Excluding a master path should not imply one: %v.", err)
+	}
+	opts.Level = ""
+
+	opts.Constraints = []string{"ancestry in Dwarf", "no Dwarf"}
+	_, err = NewCharacter(opts)
+	if cerr, ok := err.(*ConstraintError); !ok || cerr.Reason == "" {
+		t.Errorf("Expected an unsatisfiable constraint error, got %v.", err)
+	}
+	opts.Constraints = []string{"strength > 40"}
+	_, err = NewCharacter(opts)
+	if cerr, ok := err.(*ConstraintError); !ok || cerr.Failures["strength > 40"] != maxConstraintAttempts {
+		t.Errorf("Expected a constraint error explaining the failures, got %v.", err)
+	}
+	opts.Constraints = []string{"luck > 3"}
+	if _, err = NewCharacter(opts); err == nil {
+		t.Error("Expected an error for an invalid constraint.")
+	}
+}
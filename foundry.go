@@ -0,0 +1,239 @@
+// Foundry VTT actor export for the Demonlord game system.
+
+package sotdlgen
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+func init() {
+	RegisterRenderer("foundry", foundryRenderer{})
+}
+
+// FoundryActor is a Foundry VTT actor document for the Demonlord system.
+type FoundryActor struct {
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	System FoundrySystem `json:"system"`
+	Items  []FoundryItem `json:"items"`
+	Flags  FoundryFlags  `json:"flags"`
+}
+
+// FoundrySystem holds the system-specific actor data.
+type FoundrySystem struct {
+	Attributes      FoundryAttributes      `json:"attributes"`
+	Characteristics FoundryCharacteristics `json:"characteristics"`
+	Level           int                    `json:"level"`
+	Ancestry        string                 `json:"ancestry"`
+	Professions     string                 `json:"professions"`
+	Languages       string                 `json:"languages"`
+}
+
+// FoundryAttributes holds the actor's attribute scores.
+type FoundryAttributes struct {
+	Strength   FoundryValue `json:"strength"`
+	Agility    FoundryValue `json:"agility"`
+	Intellect  FoundryValue `json:"intellect"`
+	Will       FoundryValue `json:"will"`
+	Perception FoundryValue `json:"perception"`
+}
+
+// FoundryValue is an attribute score and its modifier.
+type FoundryValue struct {
+	Value    int `json:"value"`
+	Modifier int `json:"modifier"`
+	Min      int `json:"min"`
+	Max      int `json:"max"`
+}
+
+// FoundryCharacteristics holds the actor's characteristics.
+type FoundryCharacteristics struct {
+	Health     FoundryHealth `json:"health"`
+	Defense    int           `json:"defense"`
+	Power      int           `json:"power"`
+	Speed      int           `json:"speed"`
+	Size       string        `json:"size"`
+	Insanity   FoundryPool   `json:"insanity"`
+	Corruption FoundryPool   `json:"corruption"`
+}
+
+// FoundryHealth holds the actor's Health, damage and healing rate.
+type FoundryHealth struct {
+	Max         int `json:"max"`
+	Value       int `json:"value"`
+	HealingRate int `json:"healingrate"`
+}
+
+// FoundryPool is a characteristic that accrues up to a maximum.
+type FoundryPool struct {
+	Value int `json:"value"`
+	Max   int `json:"max"`
+}
+
+// FoundryItem is an item embedded in an actor: its ancestry, paths,
+// talents, spells and gear.
+type FoundryItem struct {
+	Name   string                 `json:"name"`
+	Type   string                 `json:"type"`
+	System map[string]interface{} `json:"system"`
+}
+
+// FoundryFlags holds sotdlgen data with no Demonlord equivalent.
+type FoundryFlags struct {
+	Sotdlgen FoundryGenFlags `json:"sotdlgen"`
+}
+
+// FoundryGenFlags records the fields needed to restore a character from
+// an exported actor.
+type FoundryGenFlags struct {
+	Gender           string   `json:"gender"`
+	Pronouns         Pronouns `json:"pronouns"`
+	Seed             string   `json:"seed"`
+	ApparentAncestry string   `json:"apparent_ancestry,omitempty"`
+	ApparentGender   string   `json:"apparent_gender,omitempty"`
+	ApparentName     string   `json:"apparent_name,omitempty"`
+	Purpose          string   `json:"purpose,omitempty"`
+	KeyLocation      string   `json:"key_location,omitempty"`
+	// The Demonlord system keeps languages and professions as free text.
+	LangAndProf []string `json:"languages_and_professions,omitempty"`
+}
+
+// Maximum attribute score.
+const maxAttribute = 20
+
+func foundryValue(score int) FoundryValue {
+	return FoundryValue{Value: score, Modifier: modifier(score), Max: maxAttribute}
+}
+
+// ToFoundry maps the character onto a Foundry VTT Demonlord actor.
+func (c Character) ToFoundry() FoundryActor {
+	a := c.Attributes
+	actor := FoundryActor{
+		Name: c.Name,
+		Type: "character",
+		System: FoundrySystem{
+			Attributes: FoundryAttributes{
+				Strength:   foundryValue(a.Strength),
+				Agility:    foundryValue(a.Agility),
+				Intellect:  foundryValue(a.Intellect),
+				Will:       foundryValue(a.Will),
+				Perception: foundryValue(a.Perception),
+			},
+			Characteristics: FoundryCharacteristics{
+				Health:     FoundryHealth{Max: a.Health, HealingRate: a.HealingRate},
+				Defense:    a.Defense,
+				Power:      a.Power,
+				Speed:      a.Speed,
+				Size:       a.Size,
+				Insanity:   FoundryPool{Value: a.Insanity, Max: a.Will},
+				Corruption: FoundryPool{Value: a.Corruption, Max: maxAttribute},
+			},
+			Level:    c.Level,
+			Ancestry: c.Ancestry,
+		},
+		Items: []FoundryItem{},
+		Flags: FoundryFlags{FoundryGenFlags{
+			Gender:           c.Gender,
+			Pronouns:         c.Pronouns,
+			Seed:             c.Seed,
+			ApparentAncestry: c.ApparentAncestry,
+			ApparentGender:   c.ApparentGender,
+			ApparentName:     c.ApparentName,
+			Purpose:          c.Purpose,
+			KeyLocation:      c.KeyLocation,
+			LangAndProf:      c.LangAndProf,
+		}},
+	}
+	langs, profs := []string{}, []string{}
+	for _, lp := range c.LangAndProf {
+		if strings.Contains(strings.ToLower(lp), "speak") {
+			langs = append(langs, lp)
+		} else {
+			profs = append(profs, lp)
+		}
+	}
+	actor.System.Languages = strings.Join(langs, " ")
+	actor.System.Professions = strings.Join(profs, " ")
+	add := func(name, typ string, system map[string]interface{}) {
+		actor.Items = append(actor.Items, FoundryItem{name, typ, system})
+	}
+	add(c.Ancestry, "ancestry", map[string]interface{}{})
+	tiers := []struct{ tier, path string }{
+		{"novice", c.NovicePath}, {"expert", c.ExpertPath}, {"master", c.MasterPath},
+	}
+	for _, t := range tiers {
+		if t.path != "" {
+			add(t.path, "path", map[string]interface{}{"type": t.tier})
+		}
+	}
+	for _, t := range c.Talents {
+		add(summary(t), "talent", map[string]interface{}{"description": t})
+	}
+	for _, sp := range c.Magic {
+		add(sp.Name, "spell", map[string]interface{}{
+			"tradition":   sp.Type,
+			"rank":        sp.Rank,
+			"target":      sp.Target,
+			"area":        sp.Area,
+			"duration":    sp.Duration,
+			"triggered":   sp.Triggered,
+			"sacrifice":   sp.Sacrifice,
+			"permanence":  sp.Permanence,
+			"attack20":    sp.AttackRoll20,
+			"description": sp.Description,
+		})
+	}
+	for _, wp := range c.Weapons {
+		add(wp.Name, "weapon", map[string]interface{}{
+			"action":     map[string]interface{}{"damage": wp.Damage.toStr()},
+			"type":       wp.Type,
+			"hands":      strings.ToLower(wp.Hands),
+			"range":      wp.Range,
+			"reach":      wp.Reach,
+			"size":       wp.Size,
+			"uses":       wp.Uses,
+			"defense":    wp.DefenseBonus,
+			"properties": wp.properties(),
+		})
+	}
+	for _, ar := range c.Armor {
+		add(ar.Name, "armor", map[string]interface{}{
+			"defense": ar.DefenseBonus,
+			"type":    ar.Type,
+		})
+	}
+	for _, eq := range c.Equipment {
+		add(eq, "item", map[string]interface{}{})
+	}
+	return actor
+}
+
+// properties lists a weapon's properties as written in the rules.
+func (wp Weapon) properties() string {
+	props := []string{}
+	flags := []struct {
+		set  bool
+		name string
+	}{
+		{wp.Cumbersome, "Cumbersome"}, {wp.Finesse, "Finesse"}, {wp.Misfire, "Misfire"},
+		{wp.Reload, "Reload"}, {wp.Thrown, "Thrown"},
+	}
+	for _, f := range flags {
+		if f.set {
+			props = append(props, f.name)
+		}
+	}
+	return strings.Join(props, ", ")
+}
+
+type foundryRenderer struct{}
+
+func (foundryRenderer) ContentType() string { return "application/json" }
+
+func (foundryRenderer) Render(w io.Writer, c Character) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c.ToFoundry())
+}
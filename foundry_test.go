@@ -0,0 +1,59 @@
+package sotdlgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func loadFixture(t *testing.T) Character {
+	raw, err := ioutil.ReadFile("./testdata/character.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c Character
+	if err = json.Unmarshal(raw, &c); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestToFoundry(t *testing.T) {
+	c := loadFixture(t)
+	b := &bytes.Buffer{}
+	if err := c.Render(b, "foundry"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The export matches the expected actor document. The document was
+	// produced by this exporter, so it only guards against regressions.
+	golden, err := ioutil.ReadFile("./testdata/foundry_actor.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), golden) {
+		t.Error("Foundry export does not match testdata/foundry_actor.json.")
+	}
+
+	var actor FoundryActor
+	if err = json.Unmarshal(b.Bytes(), &actor); err != nil {
+		t.Fatal(err)
+	}
+
+	if actor.System.Attributes.Strength.Value != 13 || actor.System.Attributes.Strength.Modifier != 3 {
+		t.Error("Incorrect strength mapping.")
+	}
+	if actor.System.Characteristics.Health.Max != 28 {
+		t.Error("Incorrect health mapping.")
+	}
+	types := map[string]int{}
+	for _, it := range actor.Items {
+		types[it.Type]++
+	}
+	want := map[string]int{"ancestry": 1, "path": 2, "talent": 2, "spell": 1, "weapon": 1, "armor": 1, "item": 2}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("Incorrect items. Expected %v, got %v.", want, types)
+	}
+}
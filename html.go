@@ -0,0 +1,11 @@
+// Self-contained HTML character sheet.
+
+package sotdlgen
+
+func init() {
+	r, err := NewTemplateRenderer("sheet.html")
+	if err != nil {
+		panic(err)
+	}
+	RegisterRenderer("html", r)
+}
@@ -0,0 +1,30 @@
+package sotdlgen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHTMLRenderer(t *testing.T) {
+	c := testCharacter
+	c.Magic = []Spell{{Name: "Flame Missile", Type: "Attack", Rank: 1, Description: "A dart of fire."}}
+	b := &bytes.Buffer{}
+	if err := c.Render(b, "html"); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, s := range []string{
+		"<style>", "@media print", "<h1>Borkenhekenaken</h1>",
+		"<details><summary>Shadowsight</summary>", "Flame Missile",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("HTML sheet is missing '%s'.", s)
+		}
+	}
+	for _, s := range []string{"<link", "src=\"http", "href=\"http"} {
+		if strings.Contains(out, s) {
+			t.Errorf("HTML sheet is not self-contained; found '%s'.", s)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Advancement of existing characters.
+
+package sotdlgen
+
+import "fmt"
+
+// LevelUp advances the character to the given level, adding the benefits of
+// each level gained and entering a new path as each tier opens, just as
+// generation does. New paths are taken from opts if set and chosen at random
+// otherwise; the random choices are seeded from opts.Seed, or else from the
+// character's seed and the new level, so the same advancement can be
+// repeated.
+func (c *Character) LevelUp(level int, opts Opts) error {
+	if level <= c.Level || level > maxLevel {
+		return ValidationError{FieldError{"level", fmt.Sprintf(
+			"must be in [%d..%d], got %d", c.Level+1, maxLevel, level)}}
+	}
+	for field, path := range map[string]string{
+		"novice_path": opts.NovicePath, "expert_path": opts.ExpertPath, "master_path": opts.MasterPath,
+	} {
+		if path != "" && PathTier(path)+"_path" != field {
+			return ValidationError{FieldError{field, fmt.Sprintf("unknown path '%s'", path)}}
+		}
+	}
+	if err := LoadCharDB(opts.DataFile); err != nil {
+		return err
+	}
+	seed := opts.Seed
+	if seed == "" {
+		seed = deriveSeed(c.Seed, level)
+	}
+	if _, err := setSeed(seed); err != nil {
+		return err
+	}
+	c.advance(level, opts.pathFor(c))
+	return nil
+}
+
+// advance raises the character to the given level, adding the benefits of
+// each level gained by its ancestry and paths, and entering the path pathFor
+// returns, or one drawn at random if it returns "", as each tier opens. New
+// characters are generated by advancing them from level 0, so that they
+// match characters levelled up to the same level.
+func (c *Character) advance(level int, pathFor func(tier int) string) {
+	for l := c.Level + 1; l <= level; l++ {
+		c.Level = l
+		for _, p := range []string{c.Ancestry, c.NovicePath, c.ExpertPath, c.MasterPath} {
+			if p != "" {
+				c.applyLevel(p, l)
+			}
+		}
+		switch l {
+		case noviceLevel, expertLevel, masterLevel:
+			c.setPath(pathFor(l))
+		}
+	}
+	c.calcDerived()
+	c.calcHealingRate()
+}
+
+// pathFor returns the path the options give for the tier opening at the
+// given level. A master path, if not given, is drawn from the narrowed
+// master paths the character qualifies for, if any.
+func (opts Opts) pathFor(c *Character) func(tier int) string {
+	return func(tier int) string {
+		switch tier {
+		case noviceLevel:
+			return opts.NovicePath
+		case expertLevel:
+			return opts.ExpertPath
+		}
+		if opts.MasterPath == "" && len(opts.masterPaths) > 0 {
+			// Failing that, any is drawn, and the constraints reject it.
+			if eligible := c.eligibleAmong(opts.masterPaths); len(eligible) > 0 {
+				return randomChoice(eligible)
+			}
+		}
+		return opts.MasterPath
+	}
+}
@@ -0,0 +1,58 @@
+package sotdlgen
+
+import "testing"
+
+func TestLevelUp(t *testing.T) {
+	useTestDB(t)
+	c, err := NewCharacter(Opts{Seed: "1575d911f49e59ee", Level: "0", Ancestry: "Orc", LogLevel: "ERROR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	health := c.Attributes.Health
+	if err = c.LevelUp(3, Opts{NovicePath: "Warrior"}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Level != 3 || c.NovicePath != "Warrior" || c.ExpertPath == "" || c.MasterPath != "" {
+		t.Errorf("Incorrect paths at level %d: %v.", c.Level, c.Paths())
+	}
+	if c.Attributes.Health <= health {
+		t.Errorf("Health did not increase from %d.", health)
+	}
+	again, _ := NewCharacter(Opts{Seed: "1575d911f49e59ee", Level: "0", Ancestry: "Orc", LogLevel: "ERROR"})
+	again.LevelUp(3, Opts{NovicePath: "Warrior"})
+	if again.ExpertPath != c.ExpertPath || again.Attributes != c.Attributes {
+		t.Error("Repeated advancement differs.")
+	}
+	if err = c.LevelUp(8, Opts{}); err != nil {
+		t.Fatal(err)
+	}
+	if c.MasterPath == "" || !c.qualifiesFor(c.MasterPath) {
+		t.Errorf("Expected an eligible master path, got '%s'.", c.MasterPath)
+	}
+	if err = c.Validate(); err != nil {
+		t.Error(err)
+	}
+	if err = c.LevelUp(8, Opts{}); err == nil {
+		t.Error("Expected an error for a level not above the current one.")
+	}
+	if err = again.LevelUp(9, Opts{MasterPath: "Wizard"}); err == nil {
+		t.Error("Expected an error for an expert path given as a master path.")
+	}
+}
+
+func TestAncestryLevelBenefits(t *testing.T) {
+	useTestDB(t)
+	db.Paths["Orc"][4] = &Level{Talents: []string{"Orc Resilience"}}
+	c, _ := NewCharacter(Opts{Seed: "1575d911f49e59ee", Level: "4", Ancestry: "Orc", LogLevel: "ERROR"})
+	raised, _ := NewCharacter(Opts{Seed: "1575d911f49e59ee", Level: "3", Ancestry: "Orc", LogLevel: "ERROR"})
+	raised.LevelUp(4, Opts{})
+	for _, ch := range []Character{c, raised} {
+		if !stringIn(ch.Talents, "Orc Resilience") {
+			t.Errorf("Level 4 ancestry benefits not applied at level %d: %v.", ch.Level, ch.Talents)
+		}
+	}
+	c, _ = NewCharacter(Opts{Seed: "1575d911f49e59ee", Level: "3", Ancestry: "Orc", LogLevel: "ERROR"})
+	if stringIn(c.Talents, "Orc Resilience") {
+		t.Error("Level 4 ancestry benefits applied at level 3.")
+	}
+}
@@ -0,0 +1,256 @@
+// Loading and validation of saved characters.
+
+package sotdlgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// FieldError describes a single invalid field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists the invalid fields of a character or options.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	msgs := []string{}
+	for _, fe := range e {
+		msgs = append(msgs, fe.Field+": "+fe.Message)
+	}
+	return "invalid " + strings.Join(msgs, "; ")
+}
+
+// Minimum levels at which each path tier is entered.
+const (
+	noviceLevel = 1
+	expertLevel = 3
+	masterLevel = 7
+	maxLevel    = 10
+)
+
+// LoadCharacter reads a character saved as sotdlgen JSON or as a Foundry VTT
+// actor and validates it against the character db.
+func LoadCharacter(r io.Reader) (c Character, err error) {
+	raw, err := ioutil.ReadAll(r)
+	if err != nil {
+		return c, err
+	}
+	var probe map[string]json.RawMessage
+	if err = json.Unmarshal(raw, &probe); err != nil {
+		return c, fmt.Errorf("cannot parse character: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, ok := probe["system"]; ok {
+		var actor FoundryActor
+		if err = dec.Decode(&actor); err != nil {
+			return c, fmt.Errorf("cannot parse Foundry actor: %v", err)
+		}
+		c = actor.FromFoundry()
+	} else {
+		dec.DisallowUnknownFields()
+		if err = dec.Decode(&c); err != nil {
+			return c, fmt.Errorf("cannot parse character: %v", err)
+		}
+	}
+	if err = LoadCharDB(""); err != nil {
+		return c, err
+	}
+	if err = c.Validate(); err != nil {
+		return c, err
+	}
+	c.restoreMods()
+	return c, nil
+}
+
+// Validate checks the character's ancestry, paths and level against the
+// character db.
+func (c Character) Validate() error {
+	errs := ValidationError{}
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{field, fmt.Sprintf(format, args...)})
+	}
+	if c.Level < 0 || c.Level > maxLevel {
+		add("level", "must be in [0..%d], got %d", maxLevel, c.Level)
+	}
+	if !stringIn(ancestries, c.Ancestry) {
+		add("ancestry", "unknown ancestry '%s'", c.Ancestry)
+	}
+	tiers := []struct {
+		field string
+		path  string
+		paths []string
+		level int
+	}{
+		{"novice_path", c.NovicePath, novicePaths, noviceLevel},
+		{"expert_path", c.ExpertPath, expertPaths, expertLevel},
+		{"master_path", c.MasterPath, masterPaths, masterLevel},
+	}
+	for _, t := range tiers {
+		switch {
+		case t.path == "" && c.Level >= t.level:
+			add(t.field, "required at level %d", c.Level)
+		case t.path != "" && c.Level < t.level:
+			add(t.field, "not available below level %d", t.level)
+		case t.path != "" && !stringIn(t.paths, t.path):
+			add(t.field, "unknown path '%s'", t.path)
+		}
+	}
+	for _, p := range []string{c.Ancestry, c.NovicePath, c.ExpertPath, c.MasterPath} {
+		if _, ok := db.Paths[p]; p != "" && !ok && len(db.Paths) > 0 {
+			add("paths", "'%s' is not in the character db", p)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// restoreMods recomputes the derived-characteristic modifiers, which are not
+// saved, from the character's ancestry and paths.
+func (c *Character) restoreMods() {
+	a := &c.Attributes
+	a.healthMod, a.defenseMod, a.perceptionMod = 0, 0, 0
+	a.healingRateMultiplier = 0
+	for _, p := range []string{c.Ancestry, c.NovicePath, c.ExpertPath, c.MasterPath} {
+		for i, lvl := range db.Paths[p] {
+			if i > c.Level {
+				continue
+			}
+			a.healthMod += lvl.HealthMod
+			a.defenseMod += lvl.DefenseMod
+			a.perceptionMod += lvl.PerceptionMod
+			if lvl.HealingRate != 0.0 {
+				a.healingRateMultiplier = lvl.HealingRate
+			}
+		}
+	}
+}
+
+// FromFoundry maps a Foundry VTT Demonlord actor back onto a character.
+// Actors exported by ToFoundry restore exactly; for others, languages and
+// professions are taken from the actor's free text.
+func (actor FoundryActor) FromFoundry() Character {
+	s := actor.System
+	f := actor.Flags.Sotdlgen
+	c := Character{
+		Name:     actor.Name,
+		Gender:   f.Gender,
+		Pronouns: f.Pronouns,
+		Ancestry: s.Ancestry,
+		Level:    s.Level,
+		Attributes: Attributes{
+			Strength:    s.Attributes.Strength.Value,
+			Agility:     s.Attributes.Agility.Value,
+			Intellect:   s.Attributes.Intellect.Value,
+			Will:        s.Attributes.Will.Value,
+			Perception:  s.Attributes.Perception.Value,
+			Health:      s.Characteristics.Health.Max,
+			HealingRate: s.Characteristics.Health.HealingRate,
+			Defense:     s.Characteristics.Defense,
+			Power:       s.Characteristics.Power,
+			Speed:       s.Characteristics.Speed,
+			Size:        s.Characteristics.Size,
+			Insanity:    s.Characteristics.Insanity.Value,
+			Corruption:  s.Characteristics.Corruption.Value,
+		},
+		Seed:             f.Seed,
+		ApparentAncestry: f.ApparentAncestry,
+		ApparentGender:   f.ApparentGender,
+		ApparentName:     f.ApparentName,
+		Purpose:          f.Purpose,
+		KeyLocation:      f.KeyLocation,
+	}
+	c.LangAndProf = f.LangAndProf
+	if len(c.LangAndProf) == 0 {
+		for _, lp := range []string{s.Professions, s.Languages} {
+			if lp != "" {
+				c.LangAndProf = append(c.LangAndProf, lp)
+			}
+		}
+	}
+	for _, it := range actor.Items {
+		str := func(key string) string {
+			v, _ := it.System[key].(string)
+			return v
+		}
+		num := func(key string) int {
+			v, _ := it.System[key].(float64)
+			return int(v)
+		}
+		flag := func(key string) bool {
+			v, _ := it.System[key].(bool)
+			return v
+		}
+		switch it.Type {
+		case "ancestry":
+			if c.Ancestry == "" {
+				c.Ancestry = it.Name
+			}
+		case "path":
+			switch str("type") {
+			case "novice":
+				c.NovicePath = it.Name
+			case "expert":
+				c.ExpertPath = it.Name
+			case "master":
+				c.MasterPath = it.Name
+			}
+		case "talent":
+			desc := str("description")
+			if desc == "" {
+				desc = it.Name
+			}
+			c.Talents = append(c.Talents, desc)
+		case "spell":
+			c.Magic = append(c.Magic, Spell{
+				Name:         it.Name,
+				Type:         str("tradition"),
+				Rank:         num("rank"),
+				Target:       str("target"),
+				Area:         str("area"),
+				Duration:     str("duration"),
+				Triggered:    flag("triggered"),
+				Sacrifice:    flag("sacrifice"),
+				Permanence:   flag("permanence"),
+				AttackRoll20: str("attack20"),
+				Description:  str("description"),
+			})
+		case "weapon":
+			wp := Weapon{
+				Name:         it.Name,
+				Type:         str("type"),
+				Hands:        capitalize(str("hands")),
+				Range:        str("range"),
+				Reach:        num("reach"),
+				Size:         num("size"),
+				Uses:         str("uses"),
+				DefenseBonus: num("defense"),
+			}
+			if action, ok := it.System["action"].(map[string]interface{}); ok {
+				dmg, _ := action["damage"].(string)
+				wp.Damage.UnmarshalText([]byte(dmg))
+			}
+			props := str("properties")
+			wp.Cumbersome = strings.Contains(props, "Cumbersome")
+			wp.Finesse = strings.Contains(props, "Finesse")
+			wp.Misfire = strings.Contains(props, "Misfire")
+			wp.Reload = strings.Contains(props, "Reload")
+			wp.Thrown = strings.Contains(props, "Thrown")
+			c.Weapons = append(c.Weapons, wp)
+		case "armor":
+			c.Armor = append(c.Armor, Armor{Name: it.Name, Type: str("type"), DefenseBonus: num("defense")})
+		case "item":
+			c.Equipment = append(c.Equipment, it.Name)
+		}
+	}
+	return c
+}
@@ -0,0 +1,100 @@
+package sotdlgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// useTestDB replaces the character db with a small in-memory one for the
+// duration of a test.
+func useTestDB(t *testing.T) {
+	saved := db
+	t.Cleanup(func() { db = saved })
+	db = CharDB{}
+	db.initialize()
+	db.Paths["Orc"][0] = &Level{Strength: 11, Agility: 10, Intellect: 9, Will: 9,
+		HealthMod: 0, Speed: 10, Size: "1", HealingRate: 0.25}
+	db.Paths["Warrior"][1] = &Level{Strength: 1, Agility: 1, HealthMod: 5,
+		Talents: []string{"Catch Your Breath"}}
+	db.buildPrereqs()
+	db.AddNames("./assets/sotdl_names.json")
+}
+
+func TestLoadCharacter(t *testing.T) {
+	useTestDB(t)
+	f, err := os.Open("./testdata/character.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	c, err := LoadCharacter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "Vesna Ironjaw" || c.ExpertPath != "Fighter" || len(c.Weapons) != 1 {
+		t.Error("Character fields not loaded.")
+	}
+	if c.Weapons[0].Damage.toStr() != "1d6+1" {
+		t.Errorf("Incorrect weapon damage '%s'.", c.Weapons[0].Damage)
+	}
+	if c.Attributes.healthMod != 5 || c.Attributes.healingRateMultiplier != 0.25 {
+		t.Error("Derived modifiers not restored.")
+	}
+}
+
+func TestLoadFoundryActor(t *testing.T) {
+	useTestDB(t)
+	orig := loadFixture(t)
+	b := &bytes.Buffer{}
+	orig.Render(b, "foundry")
+	c, err := LoadCharacter(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The character round-trips exactly, as seen in its saved form.
+	got, _ := json.Marshal(c)
+	want, _ := json.Marshal(orig)
+	if !bytes.Equal(got, want) {
+		t.Errorf("Character does not round-trip through a Foundry actor.\nExpected %s,\ngot %s.", want, got)
+	}
+	if c.Attributes.healthMod != 5 {
+		t.Error("Derived modifiers not restored.")
+	}
+
+	// An actor from elsewhere has no sotdlgen flags.
+	c, err = LoadCharacter(strings.NewReader(`{"name": "Grub", "type": "character",
+		"system": {"ancestry": "Orc", "level": 1, "professions": "Soldier. Thief.",
+			"languages": "You speak the Common Tongue."},
+		"items": [{"name": "Warrior", "type": "path", "system": {"type": "novice"}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.NovicePath != "Warrior" || len(c.LangAndProf) != 2 || c.LangAndProf[0] != "Soldier. Thief." {
+		t.Errorf("Foreign Foundry actor not loaded: %+v.", c)
+	}
+}
+
+func TestLoadCharacterInvalid(t *testing.T) {
+	useTestDB(t)
+	_, err := LoadCharacter(strings.NewReader(
+		`{"name": "X", "ancestry": "Elf", "level": 4, "novice_path": "Wizard"}`))
+	verr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Expected a ValidationError, got %v.", err)
+	}
+	fields := map[string]bool{}
+	for _, fe := range verr {
+		fields[fe.Field] = true
+	}
+	for _, f := range []string{"ancestry", "novice_path", "expert_path"} {
+		if !fields[f] {
+			t.Errorf("Expected an error for '%s'.", f)
+		}
+	}
+	if _, err = LoadCharacter(strings.NewReader(`{"nmae": "X"}`)); err == nil {
+		t.Error("Expected an error for an unknown field.")
+	}
+}
@@ -0,0 +1,147 @@
+// Procedural name synthesis from the name lists.
+
+package sotdlgen
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+)
+
+// Order of the Markov chains, i.e., the number of preceding letters used to
+// choose the next one.
+const markovOrder = 2
+
+// Bounds on the length of synthesized names and the number of attempts made
+// to synthesize a novel one.
+const (
+	minNameLen     = 3
+	maxNameLen     = 12
+	maxNameRetries = 50
+)
+
+// nameModel is a letter-level Markov chain trained on a list of names.
+type nameModel struct {
+	chains map[string][]rune
+	known  map[string]bool
+}
+
+// Trained models, keyed by a hash of the training names. Once the cache is
+// full, training another model evicts an arbitrary one.
+var (
+	nameModels   = map[uint64]*nameModel{}
+	nameModelsMu sync.Mutex
+)
+
+// Maximum number of cached models.
+const maxNameModels = 64
+
+// newNameModel trains a Markov chain on the given names.
+func newNameModel(names []string) *nameModel {
+	m := &nameModel{chains: map[string][]rune{}, known: map[string]bool{}}
+	pad := strings.Repeat("^", markovOrder)
+	for _, name := range names {
+		m.known[strings.ToLower(name)] = true
+		rs := []rune(pad + strings.ToLower(name) + "$")
+		for i := markovOrder; i < len(rs); i++ {
+			prefix := string(rs[i-markovOrder : i])
+			m.chains[prefix] = append(m.chains[prefix], rs[i])
+		}
+	}
+	return m
+}
+
+// getNameModel returns a cached model for the given names, training one if
+// necessary.
+func getNameModel(names []string) *nameModel {
+	h := fnv.New64a()
+	for _, name := range names {
+		h.Write([]byte(name + "\x00"))
+	}
+	key := h.Sum64()
+	nameModelsMu.Lock()
+	defer nameModelsMu.Unlock()
+	m, ok := nameModels[key]
+	if !ok {
+		if len(nameModels) >= maxNameModels {
+			for k := range nameModels {
+				delete(nameModels, k)
+				break
+			}
+		}
+		m = newNameModel(names)
+		nameModels[key] = m
+	}
+	return m
+}
+
+// generate synthesizes a name that is not in the training list, or returns
+// the empty string if it fails to do so.
+func (m *nameModel) generate() string {
+	for i := 0; i < maxNameRetries; i++ {
+		rs := []rune(strings.Repeat("^", markovOrder))
+		for len(rs) < maxNameLen+markovOrder {
+			next := m.chains[string(rs[len(rs)-markovOrder:])]
+			if len(next) == 0 {
+				break
+			}
+			r := next[randomInt(0, len(next))]
+			if r == '$' {
+				break
+			}
+			rs = append(rs, r)
+		}
+		name := string(rs[markovOrder:])
+		if len([]rune(name)) >= minNameLen && !m.known[name] {
+			return strings.ToUpper(string(rs[markovOrder])) + string(rs[markovOrder+1:])
+		}
+	}
+	return ""
+}
+
+// synthesizeName generates a novel name in the style of the given names,
+// falling back to a verbatim pick if none can be synthesized.
+func synthesizeName(names []string) string {
+	if name := getNameModel(names).generate(); name != "" {
+		return name
+	}
+	return randomChoice(names)
+}
+
+// NameSet records the names already given out, so that a generated roster
+// never reuses one. It is safe for concurrent use.
+type NameSet struct {
+	mu    sync.Mutex
+	names map[string]bool
+}
+
+// NewNameSet creates an empty NameSet.
+func NewNameSet() *NameSet {
+	return &NameSet{names: map[string]bool{}}
+}
+
+// Contains reports whether the name has already been given out.
+func (s *NameSet) Contains(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.names[name]
+}
+
+// Add records the name, returning false if it was already present.
+func (s *NameSet) Add(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.names[name] {
+		return false
+	}
+	s.names[name] = true
+	return true
+}
+
+// Remove forgets the name, e.g., if the character it was given to has been
+// discarded.
+func (s *NameSet) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.names, name)
+}
@@ -0,0 +1,56 @@
+package sotdlgen
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSynthesizeName(t *testing.T) {
+	setSeed("1575d911f49e59ee")
+	names := []string{"Alain", "Alger", "Alnor", "Ansel", "Anson", "Arland", "Barden", "Bartley"}
+	m := newNameModel(names)
+	for i := 0; i < 20; i++ {
+		name := m.generate()
+		if name == "" {
+			continue
+		}
+		if stringIn(names, name) {
+			t.Errorf("Synthesized name '%s' is in the training list.", name)
+		}
+		if len(name) < minNameLen || len(name) > maxNameLen {
+			t.Errorf("Synthesized name '%s' has length out of bounds.", name)
+		}
+	}
+}
+
+func TestNameModelCache(t *testing.T) {
+	names := []string{"Alain", "Alger"}
+	if getNameModel(names) != getNameModel([]string{"Alain", "Alger"}) {
+		t.Error("Expected the model for the same names to be cached.")
+	}
+	for i := 0; i < 2*maxNameModels; i++ {
+		getNameModel([]string{"Alain", strconv.Itoa(i)})
+	}
+	if len(nameModels) > maxNameModels {
+		t.Errorf("Expected at most %d cached models, got %d.", maxNameModels, len(nameModels))
+	}
+}
+
+func TestSetNameUnique(t *testing.T) {
+	saved := db
+	defer func() { db = saved }()
+	db = CharDB{Names: []NameList{
+		{Ancestry: "Orc", Ethnicity: "Orc", Type: "Male", Names: []string{"Grom", "Durg"}},
+	}}
+	used := NewNameSet()
+	for i := 0; i < 10; i++ {
+		c := Character{Ancestry: "Orc", Gender: "Male"}
+		c.setName("", "", NameGenList, used)
+		if c.Name == "" {
+			t.Fatal("Missing name.")
+		}
+	}
+	if len(used.names) != 10 {
+		t.Errorf("Expected 10 distinct names, got %d.", len(used.names))
+	}
+}
@@ -0,0 +1,15 @@
+// OpenAPI description of the web service.
+
+package sotdlgen
+
+import (
+	_ "embed"
+)
+
+//go:embed assets/openapi.json
+var openAPISpec []byte
+
+// OpenAPISpec returns the OpenAPI 3 document describing the web service.
+func OpenAPISpec() []byte {
+	return append([]byte{}, openAPISpec...)
+}
@@ -0,0 +1,138 @@
+// Generation of balanced adventuring parties.
+
+package sotdlgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Party is a group of characters of the same level generated together.
+type Party struct {
+	Level   int         `json:"level"`
+	Seed    string      `json:"seed"`
+	Members []Character `json:"members"`
+}
+
+// checkRoles validates party roles, which are novice or expert paths.
+func checkRoles(roles []string, level int) error {
+	errs := ValidationError{}
+	for _, role := range roles {
+		switch {
+		case stringIn(novicePaths, role):
+		case stringIn(expertPaths, role):
+			if level < expertLevel {
+				errs = append(errs, FieldError{"roles",
+					fmt.Sprintf("role '%s' requires level %d or higher", role, expertLevel)})
+			}
+		default:
+			errs = append(errs, FieldError{"roles",
+				fmt.Sprintf("unknown role '%s'; expected a novice or expert path", role)})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// NewParty generates a party of n characters sharing a level. Each role, a
+// novice or expert path, is taken by one member in turn; with no roles
+// given, the four novice paths are covered. Ancestries are drawn without
+// repetition until all those the constraints allow have been used, and no
+// two members share a name. Options that are set apply to every member,
+// except that roles override the paths. As with NewBatch, members are
+// seeded from opts.Seed. At level 0 characters have no paths, so roles are
+// ignored.
+func NewParty(opts Opts, n int, roles []string) (p Party, err error) {
+	if n < 1 {
+		return p, ValidationError{{"size", fmt.Sprintf("must be positive, got %d", n)}}
+	}
+	if opts.Seed == "" {
+		opts.Seed = newMasterSeed()
+	}
+	p.Seed = opts.Seed
+	if _, err = setSeed(deriveSeed(p.Seed, -1)); err != nil {
+		return p, err
+	}
+	if opts.Level == "" {
+		p.Level = randomInt(0, 10)
+	} else if p.Level, err = strconv.Atoi(opts.Level); err != nil || p.Level < 0 || p.Level > maxLevel {
+		return p, ValidationError{{"level", fmt.Sprintf("must be in [0..%d], got '%s'", maxLevel, opts.Level)}}
+	}
+	if len(roles) == 0 {
+		roles = sampleWithoutReplacement(novicePaths, len(novicePaths))
+	}
+	if err = checkRoles(roles, p.Level); err != nil {
+		return p, err
+	}
+	ks, err := ParseConstraints(opts.Constraints)
+	if err != nil {
+		return p, err
+	}
+	choices := []string{}
+	for _, a := range ancestries {
+		ok := true
+		for _, k := range ks {
+			ok = ok && (k.Field != "ancestry" || k.allows(a))
+		}
+		if ok {
+			choices = append(choices, a)
+		}
+	}
+	pool := []string{}
+	if opts.UsedNames == nil {
+		opts.UsedNames = NewNameSet()
+	}
+	for i := 0; i < n; i++ {
+		o := opts
+		o.Seed = deriveSeed(p.Seed, i)
+		o.Level = strconv.Itoa(p.Level)
+		if o.Ancestry == "" && len(choices) > 0 {
+			if len(pool) == 0 {
+				pool = sampleWithoutReplacement(choices, len(choices))
+			}
+			o.Ancestry, pool = pool[0], pool[1:]
+		}
+		if role := roles[i%len(roles)]; p.Level > 0 {
+			if stringIn(novicePaths, role) {
+				o.NovicePath = role
+			} else {
+				o.ExpertPath = role
+			}
+		}
+		c, err := NewCharacter(o)
+		if err != nil {
+			return p, err
+		}
+		p.Members = append(p.Members, c)
+	}
+	return p, nil
+}
+
+// Render writes the party in the named format: json and yaml as a single
+// document, and text and markdown as consecutive character sheets.
+func (p Party) Render(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(p)
+	case "yaml":
+		return writeYAML(w, p)
+	case "text", "markdown":
+		for i, c := range p.Members {
+			if i > 0 {
+				io.WriteString(w, "\n")
+			}
+			if err := c.Render(w, format); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown party format '%s'; expected one of {json, yaml, markdown, text}", format)
+}
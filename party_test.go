@@ -0,0 +1,96 @@
+package sotdlgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewParty(t *testing.T) {
+	useTestDB(t)
+	opts := Opts{Seed: "1575d911f49e59ee", Level: "2", LogLevel: "ERROR"}
+	p, err := NewParty(opts, 6, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Members) != 6 || p.Level != 2 {
+		t.Fatalf("Expected 6 level 2 members, got %d at level %d.", len(p.Members), p.Level)
+	}
+	paths, ancs, names := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, c := range p.Members {
+		if c.Level != 2 {
+			t.Errorf("Member '%s' is level %d.", c.Name, c.Level)
+		}
+		if names[c.Name] {
+			t.Errorf("Duplicate name '%s'.", c.Name)
+		}
+		paths[c.NovicePath] = true
+		ancs[c.Ancestry] = true
+		names[c.Name] = true
+	}
+	if len(paths) != len(novicePaths) {
+		t.Errorf("Expected all novice paths to be covered, got %v.", paths)
+	}
+	if len(ancs) != len(ancestries) {
+		t.Errorf("Expected %d distinct ancestries, got %v.", len(ancestries), ancs)
+	}
+	again, _ := NewParty(opts, 6, nil)
+	if !reflect.DeepEqual(p, again) {
+		t.Error("Parties with the same seed differ.")
+	}
+}
+
+func TestNewPartyRoles(t *testing.T) {
+	useTestDB(t)
+	opts := Opts{Seed: "1575d911f49e59ee", Level: "3", LogLevel: "ERROR"}
+	p, err := NewParty(opts, 2, []string{"Warrior", "Wizard"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Members[0].NovicePath != "Warrior" || p.Members[1].ExpertPath != "Wizard" {
+		t.Errorf("Roles not assigned: %s, %s.", p.Members[0].NovicePath, p.Members[1].ExpertPath)
+	}
+	opts.Level = "1"
+	if _, err = NewParty(opts, 2, []string{"Wizard"}); !isValidationError(err) {
+		t.Errorf("Expected a validation error for an expert role below level 3, got %v.", err)
+	}
+	if _, err = NewParty(opts, 2, []string{"Bard"}); !isValidationError(err) {
+		t.Errorf("Expected a validation error for an unknown role, got %v.", err)
+	}
+	if _, err = NewParty(opts, 0, nil); !isValidationError(err) {
+		t.Errorf("Expected a validation error for an empty party, got %v.", err)
+	}
+	opts.Level = "11"
+	if _, err = NewParty(opts, 2, nil); !isValidationError(err) {
+		t.Errorf("Expected a validation error for level 11, got %v.", err)
+	}
+}
+
+func isValidationError(err error) bool {
+	_, ok := err.(ValidationError)
+	return ok
+}
+
+func TestPartyRender(t *testing.T) {
+	p := Party{Level: 2, Seed: "abc", Members: []Character{testCharacter, loadFixture(t)}}
+	b := &bytes.Buffer{}
+	if err := p.Render(b, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var doc Party
+	if err := json.Unmarshal(b.Bytes(), &doc); err != nil || len(doc.Members) != 2 {
+		t.Errorf("Incorrect party document: %v.", err)
+	}
+	b.Reset()
+	if err := p.Render(b, "markdown"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(b.String(), "\n# ") != 1 {
+		t.Errorf("Expected two Markdown sheets, got:\n%s", b.String())
+	}
+	if err := p.Render(b, "pdf"); err == nil {
+		t.Error("Expected an error for an unsupported format.")
+	}
+}
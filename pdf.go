@@ -0,0 +1,283 @@
+// Printable PDF character sheet.
+
+package sotdlgen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterRenderer("pdf", pdfRenderer{})
+}
+
+// Page geometry, in points, for US Letter.
+const (
+	pdfPageWidth  = 612.0
+	pdfPageHeight = 792.0
+	pdfMargin     = 48.0
+)
+
+// Approximate average glyph width of Helvetica as a fraction of font size,
+// used to wrap text.
+const pdfGlyphWidth = 0.5
+
+// pdfDoc is a minimal PDF writer supporting text in the standard Helvetica
+// fonts and stroked rectangles.
+type pdfDoc struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func (d *pdfDoc) page() *bytes.Buffer {
+	return d.pages[len(d.pages)-1]
+}
+
+func (d *pdfDoc) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pdfPageHeight - pdfMargin
+}
+
+// pdfString escapes text as a PDF literal string in WinAnsiEncoding.
+func pdfString(s string) string {
+	b := &strings.Builder{}
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '‘' || r == '’':
+			b.WriteByte('\'')
+		case r == '“' || r == '”':
+			b.WriteByte('"')
+		case r == '–' || r == '—':
+			b.WriteByte('-')
+		case r < 32:
+			b.WriteByte(' ')
+		case r < 128:
+			b.WriteRune(r)
+		case r < 256:
+			b.WriteString(fmt.Sprintf("\\%03o", r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// text draws a single line of text with its baseline at (x, y).
+func (d *pdfDoc) text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(d.page(), "BT /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+// rect strokes a rectangle whose lower-left corner is at (x, y).
+func (d *pdfDoc) rect(x, y, w, h float64) {
+	fmt.Fprintf(d.page(), "%.2f %.2f %.2f %.2f re S\n", x, y, w, h)
+}
+
+// line strokes a line from (x1, y1) to (x2, y2).
+func (d *pdfDoc) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(d.page(), "%.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// ensure starts a new page if fewer than h points remain on this one.
+func (d *pdfDoc) ensure(h float64) {
+	if d.y-h < pdfMargin {
+		d.newPage()
+	}
+}
+
+// paragraph draws wrapped text at the current position, starting new pages
+// as needed.
+func (d *pdfDoc) paragraph(x, size float64, bullet bool, s string) {
+	width := pdfPageWidth - pdfMargin - x
+	if bullet {
+		width -= size
+	}
+	chars := int(width / (size * pdfGlyphWidth))
+	lead := size * 1.3
+	for i, line := range strings.Split(wrapText(s, chars, 0), "\n") {
+		d.ensure(lead)
+		d.y -= lead
+		if bullet && i == 0 {
+			d.text(x, d.y, size, false, "-")
+		}
+		if bullet {
+			d.text(x+size, d.y, size, false, line)
+		} else {
+			d.text(x, d.y, size, false, line)
+		}
+	}
+}
+
+// heading draws a section heading with a rule beneath it.
+func (d *pdfDoc) heading(s string) {
+	d.ensure(48)
+	d.y -= 22
+	d.text(pdfMargin, d.y, 12, true, strings.ToUpper(s))
+	d.line(pdfMargin, d.y-4, pdfPageWidth-pdfMargin, d.y-4)
+	d.y -= 6
+}
+
+// box draws a labelled stat box with its top-left corner at (x, d.y).
+func (d *pdfDoc) box(x, w, h float64, label, value, note string) {
+	d.rect(x, d.y-h, w, h)
+	d.text(x+4, d.y-10, 7, false, strings.ToUpper(label))
+	d.text(x+6, d.y-h+8, 16, true, value)
+	if note != "" {
+		d.text(x+w-26, d.y-h+8, 10, false, note)
+	}
+}
+
+// write serializes the document.
+func (d *pdfDoc) write(w io.Writer) error {
+	b := &bytes.Buffer{}
+	offsets := []int{}
+	obj := func(body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(b, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// Objects 1-4 are the catalog, page tree and fonts; each page then takes
+	// a page object and a content stream.
+	kids := []string{}
+	for i := range d.pages {
+		kids = append(kids, strconv.Itoa(5+2*i)+" 0 R")
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, p := range d.pages {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", p.Len(), p.String()))
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+type pdfRenderer struct{}
+
+func (pdfRenderer) ContentType() string { return "application/pdf" }
+
+func (pdfRenderer) Render(w io.Writer, c Character) error {
+	a := c.Attributes
+	d := &pdfDoc{}
+	d.newPage()
+
+	// Header
+	d.y -= 20
+	d.text(pdfMargin, d.y, 22, true, c.Name)
+	d.y -= 16
+	sub := fmt.Sprintf("Level %d %s %s (%s)", c.Level, c.Gender, c.Ancestry, c.Pronouns)
+	if paths := c.Paths(); len(paths) > 0 {
+		sub += " - " + strings.Join(paths, " / ")
+	}
+	d.text(pdfMargin, d.y, 11, false, sub)
+	if c.ApparentAncestry != "" {
+		d.y -= 14
+		d.text(pdfMargin, d.y, 10, false, fmt.Sprintf("Guise: %s, a %s %s",
+			c.ApparentName, c.ApparentGender, c.ApparentAncestry))
+	}
+	if c.Purpose != "" {
+		d.y -= 14
+		d.text(pdfMargin, d.y, 10, false, fmt.Sprintf("Purpose: %s; key in %s",
+			c.Purpose, strings.ToLower(c.KeyLocation)))
+	}
+
+	// Attributes
+	d.heading("Attributes")
+	d.y -= 8
+	inner := pdfPageWidth - 2*pdfMargin
+	attrs := []struct {
+		label string
+		score int
+	}{
+		{"Strength", a.Strength}, {"Agility", a.Agility},
+		{"Intellect", a.Intellect}, {"Will", a.Will},
+	}
+	bw := (inner - 3*8) / 4
+	for i, at := range attrs {
+		d.box(pdfMargin+float64(i)*(bw+8), bw, 40, at.label,
+			strconv.Itoa(at.score), signed(modifier(at.score)))
+	}
+	d.y -= 40
+
+	// Characteristics
+	d.heading("Characteristics")
+	d.y -= 8
+	chars := []struct{ label, value string }{
+		{"Health", strconv.Itoa(a.Health)}, {"Healing Rate", strconv.Itoa(a.HealingRate)},
+		{"Defense", strconv.Itoa(a.Defense)}, {"Perception", strconv.Itoa(a.Perception)},
+		{"Speed", strconv.Itoa(a.Speed)}, {"Power", strconv.Itoa(a.Power)},
+		{"Size", a.Size}, {"Insanity", strconv.Itoa(a.Insanity)},
+		{"Corruption", strconv.Itoa(a.Corruption)},
+	}
+	cw := (inner - 4*8) / 5
+	for i, ch := range chars {
+		if i == 5 {
+			d.y -= 42
+		}
+		d.box(pdfMargin+float64(i%5)*(cw+8), cw, 34, ch.label, ch.value, "")
+	}
+	d.y -= 34
+
+	// Lists
+	gear := []string{}
+	for _, wp := range c.Weapons {
+		gear = append(gear, fmt.Sprintf("%s (%s, %s damage)", wp.Name, wp.Hands, wp.Damage))
+	}
+	for _, ar := range c.Armor {
+		gear = append(gear, fmt.Sprintf("%s (Defense %d)", ar.Name, ar.DefenseBonus))
+	}
+	gear = append(gear, c.Equipment...)
+	spells := []string{}
+	for _, sp := range c.Magic {
+		spells = append(spells, fmt.Sprintf("%s (%s %d): %s", sp.Name, sp.Type, sp.Rank, sp.Description))
+	}
+	sections := []struct {
+		title string
+		items []string
+	}{
+		{"Languages and Professions", c.LangAndProf},
+		{"Talents", c.Talents},
+		{"Gear", gear},
+		{"Spells", spells},
+	}
+	for _, s := range sections {
+		if len(s.items) == 0 {
+			continue
+		}
+		d.heading(s.title)
+		for _, item := range s.items {
+			d.paragraph(pdfMargin, 9.5, true, item)
+			d.y -= 2
+		}
+	}
+
+	// Footer on every page.
+	for i := range d.pages {
+		fmt.Fprintf(d.pages[i], "BT /F1 7 Tf %.2f %.2f Td %s Tj ET\n", pdfMargin, pdfMargin/2,
+			pdfString(fmt.Sprintf("Seed %s - page %d of %d", c.Seed, i+1, len(d.pages))))
+	}
+	return d.write(w)
+}
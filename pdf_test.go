@@ -0,0 +1,42 @@
+package sotdlgen
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDFRenderer(t *testing.T) {
+	c := testCharacter
+	c.Talents = append(c.Talents, strings.Repeat("A long (parenthesized) talent description. ", 20))
+	b := &bytes.Buffer{}
+	if err := c.Render(b, "pdf"); err != nil {
+		t.Fatal(err)
+	}
+	out := b.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("Output is not a PDF document.")
+	}
+	if !bytes.Contains(out, []byte("(Borkenhekenaken)")) {
+		t.Error("PDF is missing the character's name.")
+	}
+	if !bytes.Contains(out, []byte(`\(parenthesized\)`)) {
+		t.Error("PDF text is not escaped.")
+	}
+
+	// Every cross-reference entry must point at its object.
+	i := bytes.LastIndex(out, []byte("startxref\n"))
+	xref, _ := strconv.Atoi(strings.Fields(string(out[i+10:]))[0])
+	entries := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(out[xref:], -1)
+	if len(entries) == 0 {
+		t.Fatal("PDF has no cross-reference entries.")
+	}
+	for n, e := range entries {
+		off, _ := strconv.Atoi(string(e[1]))
+		if !bytes.HasPrefix(out[off:], []byte(strconv.Itoa(n+1)+" 0 obj")) {
+			t.Errorf("Cross-reference entry %d is incorrect.", n+1)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Master path prerequisites and magic traditions.
+
+package sotdlgen
+
+// Prereq describes what a character needs before entering a master path. A
+// character must satisfy every non-empty requirement; within a requirement,
+// any one entry suffices.
+type Prereq struct {
+	Paths      []string `json:"paths,omitempty"`
+	Traditions []string `json:"traditions,omitempty"`
+}
+
+// anyTradition marks a path that may discover any tradition.
+const anyTradition = "*"
+
+// Traditions each novice and expert path can discover.
+var pathTraditions = map[string][]string{
+	"Magician":    {anyTradition},
+	"Priest":      {"Theurgy", "Celestial", "Life", "Protection", "Divination"},
+	"Artificer":   {"Alteration", "Technomancy", "Rune", "Telekinesis"},
+	"Cleric":      {"Theurgy", "Celestial", "Life", "Protection", "Spiritualism"},
+	"Druid":       {"Primal", "Nature", "Air", "Earth", "Storm", "Water"},
+	"Oracle":      {"Divination", "Celestial", "Time", "Spiritualism"},
+	"Paladin":     {"Battle", "Protection", "Theurgy"},
+	"Sorcerer":    {anyTradition},
+	"Spellbinder": {"Battle", "Enchantment", "Telekinesis"},
+	"Warlock":     {"Curse", "Forbidden", "Necromancy", "Shadow", "Death"},
+	"Witch":       {"Curse", "Enchantment", "Primal", "Transformation"},
+	"Wizard":      {anyTradition},
+}
+
+// Requirements for the master paths that have them. Master paths absent from
+// this map are open to everyone.
+var masterPathPrereqs = map[string]Prereq{
+	"Abjurer":            {Traditions: []string{"Protection"}},
+	"Aeromancer":         {Traditions: []string{"Air"}},
+	"Apocalyptist":       {Traditions: []string{"Destruction"}},
+	"Arcanist":           {Traditions: []string{"Arcana"}},
+	"Astromancer":        {Traditions: []string{"Celestial"}},
+	"Chaplain":           {Paths: []string{"Priest"}},
+	"Chronomancer":       {Traditions: []string{"Time"}},
+	"Conjurer":           {Traditions: []string{"Conjuration"}},
+	"Diviner":            {Traditions: []string{"Divination"}},
+	"Enchantment":        {Traditions: []string{"Enchantment"}},
+	"Engineer":           {Paths: []string{"Artificer"}},
+	"Exorcist":           {Traditions: []string{"Spiritualism", "Theurgy"}},
+	"Geomancer":          {Traditions: []string{"Earth"}},
+	"Gunslinger":         {Paths: []string{"Fighter", "Ranger", "Scout", "Thief", "Assassin"}},
+	"Healer":             {Traditions: []string{"Life"}},
+	"Hexer":              {Traditions: []string{"Curse"}},
+	"Hydromancer":        {Traditions: []string{"Water"}},
+	"Illusionist":        {Traditions: []string{"Illusion"}},
+	"Inquisitor":         {Paths: []string{"Priest"}},
+	"Mage Knight":        {Paths: []string{"Warrior", "Fighter", "Paladin", "Spellbinder"}, Traditions: []string{"Battle"}},
+	"Magus":              {Paths: []string{"Magician"}},
+	"Miracle Worker":     {Paths: []string{"Priest"}, Traditions: []string{"Theurgy"}},
+	"Necromancer":        {Traditions: []string{"Necromancy"}},
+	"Pyromancer":         {Traditions: []string{"Fire"}},
+	"Runesmith":          {Traditions: []string{"Rune"}},
+	"Shapeshifter":       {Traditions: []string{"Transformation", "Primal"}},
+	"Stormbringer":       {Traditions: []string{"Storm"}},
+	"Technomancer":       {Traditions: []string{"Technomancy"}},
+	"Templar":            {Paths: []string{"Priest", "Paladin", "Cleric"}},
+	"Tenebrist":          {Traditions: []string{"Shadow"}},
+	"Thaumaturge":        {Traditions: []string{"Alteration", "Arcana"}},
+	"Theurge":            {Traditions: []string{"Theurgy"}},
+	"Transmuter":         {Traditions: []string{"Alteration"}},
+	"Weapon Master":      {Paths: []string{"Warrior"}},
+	"Woodwose":           {Traditions: []string{"Nature", "Primal"}},
+	"Zealot":             {Paths: []string{"Priest"}},
+	"Death Dealer":       {Paths: []string{"Assassin", "Thief", "Rogue"}},
+	"Infiltrator":        {Paths: []string{"Rogue"}},
+	"Sharpshooter":       {Paths: []string{"Ranger", "Scout", "Fighter"}},
+	"Beastmaster":        {Paths: []string{"Druid", "Ranger"}},
+	"Jack-of-all-Trades": {Paths: []string{"Rogue"}},
+}
+
+// buildPrereqs populates the prerequisite and tradition tables.
+func (db *CharDB) buildPrereqs() {
+	db.Prereqs = make(map[string]Prereq, len(masterPathPrereqs))
+	for k, v := range masterPathPrereqs {
+		db.Prereqs[k] = v
+	}
+	db.Traditions = make(map[string][]string, len(pathTraditions))
+	for k, v := range pathTraditions {
+		db.Traditions[k] = v
+	}
+}
+
+// traditions lists the traditions the character's current paths can discover.
+func (c *Character) traditions() []string {
+	var ts []string
+	for _, p := range []string{c.NovicePath, c.ExpertPath} {
+		ts = append(ts, db.Traditions[p]...)
+	}
+	return ts
+}
+
+// qualifiesFor reports whether the character meets the prerequisites of the
+// given master path.
+func (c *Character) qualifiesFor(path string) bool {
+	pr, ok := db.Prereqs[path]
+	if !ok {
+		return true
+	}
+	if len(pr.Paths) > 0 && !stringIn(pr.Paths, c.NovicePath) && !stringIn(pr.Paths, c.ExpertPath) {
+		return false
+	}
+	if len(pr.Traditions) > 0 {
+		ts := c.traditions()
+		if stringIn(ts, anyTradition) {
+			return true
+		}
+		for _, t := range pr.Traditions {
+			if stringIn(ts, t) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// eligibleMasterPaths lists the master paths the character qualifies for.
+func (c *Character) eligibleMasterPaths() []string {
+	return c.eligibleAmong(masterPaths)
+}
+
+// eligibleAmong lists the given master paths the character qualifies for.
+func (c *Character) eligibleAmong(masters []string) []string {
+	paths := []string{}
+	for _, p := range masters {
+		if c.qualifiesFor(p) {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
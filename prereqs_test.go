@@ -0,0 +1,50 @@
+package sotdlgen
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEligibleMasterPaths(t *testing.T) {
+	saved := db
+	t.Cleanup(func() { db = saved })
+	db.buildPrereqs()
+
+	fighter := Character{NovicePath: "Warrior", ExpertPath: "Fighter"}
+	if fighter.qualifiesFor("Pyromancer") {
+		t.Error("Warrior/Fighter should not qualify for Pyromancer.")
+	}
+	if !fighter.qualifiesFor("Weapon Master") {
+		t.Error("Warrior/Fighter should qualify for Weapon Master.")
+	}
+	if !fighter.qualifiesFor("Brute") {
+		t.Error("Master paths without prerequisites should be open to all.")
+	}
+
+	wizard := Character{NovicePath: "Magician", ExpertPath: "Wizard"}
+	if !wizard.qualifiesFor("Pyromancer") {
+		t.Error("Magician/Wizard should qualify for Pyromancer.")
+	}
+
+	for _, p := range fighter.eligibleMasterPaths() {
+		if !fighter.qualifiesFor(p) {
+			t.Errorf("Ineligible master path '%s' offered.", p)
+		}
+	}
+	if len(fighter.eligibleMasterPaths()) >= len(wizard.eligibleMasterPaths()) {
+		t.Error("Expected a spellcaster to qualify for more master paths.")
+	}
+}
+
+func TestPrereqsNotSaved(t *testing.T) {
+	db := CharDB{}
+	db.buildPrereqs()
+	raw, err := json.Marshal(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "Weapon Master") {
+		t.Error("Prerequisite tables should be rebuilt on load, not saved.")
+	}
+}
@@ -0,0 +1,265 @@
+// Output format renderers.
+
+package sotdlgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Renderer writes a character in a particular output format.
+type Renderer interface {
+	Render(w io.Writer, c Character) error
+	ContentType() string
+}
+
+// Registered renderers, keyed by format name.
+var (
+	renderers   = map[string]Renderer{}
+	renderersMu sync.RWMutex
+)
+
+func init() {
+	RegisterRenderer("json", jsonRenderer{})
+	RegisterRenderer("yaml", yamlRenderer{})
+	RegisterRenderer("markdown", markdownRenderer{})
+	RegisterRenderer("text", TextRenderer{})
+}
+
+// RegisterRenderer makes a renderer available under the given format name,
+// replacing any renderer already registered under it.
+func RegisterRenderer(name string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[strings.ToLower(name)] = r
+}
+
+// GetRenderer returns the renderer registered under the given format name.
+func GetRenderer(name string) (Renderer, error) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	r, ok := renderers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown format '%s'; expected one of {%s}",
+			name, strings.Join(rendererNames(), ", "))
+	}
+	return r, nil
+}
+
+// RendererNames lists the registered format names.
+func RendererNames() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	return rendererNames()
+}
+
+func rendererNames() []string {
+	names := []string{}
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RendererForAccept returns the name and renderer of the most preferred
+// format whose content type matches the given Accept header, or false if none
+// does. Media ranges are tried in descending order of their q-values, more
+// specific ranges first for equal q-values (e.g., text/html before text/*
+// before */*), and then in header order; those with q=0 are skipped. Where
+// several formats share a content type, the one named for its subtype (e.g.,
+// "json" for application/json) is preferred. A wildcard range prefers json,
+// then text, then the first matching format by name.
+func RendererForAccept(accept string) (string, Renderer, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	type mediaRange struct {
+		mt string
+		q  float64
+	}
+	// specificity ranks */* below type/* below type/subtype.
+	specificity := func(mt string) int {
+		switch {
+		case mt == "*/*":
+			return 0
+		case strings.HasSuffix(mt, "/*"):
+			return 1
+		}
+		return 2
+	}
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(s, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mt, q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].q != ranges[j].q {
+			return ranges[i].q > ranges[j].q
+		}
+		return specificity(ranges[i].mt) > specificity(ranges[j].mt)
+	})
+	for _, r := range ranges {
+		wildcard := specificity(r.mt) < 2
+		match := ""
+		for _, name := range rendererNames() {
+			rt, _, _ := mime.ParseMediaType(renderers[name].ContentType())
+			if !mediaTypeMatches(r.mt, rt) {
+				continue
+			}
+			switch {
+			case match == "":
+				match = name
+			case wildcard && (name == "json" || (name == "text" && match != "json")):
+				match = name
+			case !wildcard && strings.HasSuffix(r.mt, "/"+name):
+				match = name
+			}
+		}
+		if match != "" {
+			return match, renderers[match], true
+		}
+	}
+	return "", nil, false
+}
+
+// mediaTypeMatches reports whether the media type mt falls in the media
+// range r, e.g., "text/*".
+func mediaTypeMatches(r, mt string) bool {
+	if r == "*/*" {
+		return true
+	}
+	if strings.HasSuffix(r, "/*") {
+		return strings.HasPrefix(mt, strings.TrimSuffix(r, "*"))
+	}
+	return r == mt
+}
+
+// Render writes the character in the named format.
+func (c Character) Render(w io.Writer, format string) error {
+	r, err := GetRenderer(format)
+	if err != nil {
+		return err
+	}
+	return r.Render(w, c)
+}
+
+type jsonRenderer struct{}
+
+func (jsonRenderer) ContentType() string { return "application/json" }
+
+func (jsonRenderer) Render(w io.Writer, c Character) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
+
+type yamlRenderer struct{}
+
+func (yamlRenderer) ContentType() string { return "application/yaml" }
+
+func (yamlRenderer) Render(w io.Writer, c Character) error {
+	return writeYAML(w, c)
+}
+
+// TextRenderer writes the plain-text sheet wrapped to Width columns, or to
+// the default width if Width is 0. The registered renderer uses the default,
+// since the process's terminal says nothing about where the text is read.
+type TextRenderer struct {
+	Width int
+}
+
+func (TextRenderer) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (r TextRenderer) Render(w io.Writer, c Character) error {
+	width := r.Width
+	if width == 0 {
+		width = defaultWidth
+	}
+	return c.WriteText(w, width)
+}
+
+type markdownRenderer struct{}
+
+func (markdownRenderer) ContentType() string { return "text/markdown; charset=utf-8" }
+
+func (markdownRenderer) Render(w io.Writer, c Character) error {
+	a := c.Attributes
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# %s\n\n", c.Name)
+	fmt.Fprintf(b, "*Level %d %s %s (%s)*\n\n", c.Level, c.Gender, c.Ancestry, c.Pronouns)
+	if paths := c.Paths(); len(paths) > 0 {
+		fmt.Fprintf(b, "**Paths:** %s\n\n", strings.Join(paths, " / "))
+	}
+	if c.ApparentAncestry != "" {
+		fmt.Fprintf(b, "**Guise:** %s, a %s %s\n\n", c.ApparentName, c.ApparentGender, c.ApparentAncestry)
+	}
+	if c.Purpose != "" {
+		fmt.Fprintf(b, "**Purpose:** %s; key in %s\n\n", c.Purpose, strings.ToLower(c.KeyLocation))
+	}
+	fmt.Fprint(b, "## Attributes\n\n")
+	fmt.Fprint(b, "| Strength | Agility | Intellect | Will |\n|---|---|---|---|\n")
+	fmt.Fprintf(b, "| %d (%s) | %d (%s) | %d (%s) | %d (%s) |\n\n",
+		a.Strength, signed(modifier(a.Strength)), a.Agility, signed(modifier(a.Agility)),
+		a.Intellect, signed(modifier(a.Intellect)), a.Will, signed(modifier(a.Will)))
+	fmt.Fprint(b, "## Characteristics\n\n")
+	fmt.Fprint(b, "| Health | Healing Rate | Size | Defense | Perception | Speed | Power | Insanity | Corruption |\n")
+	fmt.Fprint(b, "|---|---|---|---|---|---|---|---|---|\n")
+	fmt.Fprintf(b, "| %d | %d | %s | %d | %d (%s) | %d | %d | %d | %d |\n",
+		a.Health, a.HealingRate, a.Size, a.Defense, a.Perception, signed(modifier(a.Perception)),
+		a.Speed, a.Power, a.Insanity, a.Corruption)
+	writeMarkdownList(b, "Languages and Professions", c.LangAndProf)
+	writeMarkdownList(b, "Talents", c.Talents)
+	if len(c.Weapons) > 0 {
+		items := []string{}
+		for _, wp := range c.Weapons {
+			items = append(items, fmt.Sprintf("**%s** (%s, %s damage)", wp.Name, wp.Hands, wp.Damage.toStr()))
+		}
+		writeMarkdownList(b, "Weapons", items)
+	}
+	if len(c.Armor) > 0 {
+		items := []string{}
+		for _, ar := range c.Armor {
+			items = append(items, fmt.Sprintf("**%s** (Defense %d)", ar.Name, ar.DefenseBonus))
+		}
+		writeMarkdownList(b, "Armor", items)
+	}
+	writeMarkdownList(b, "Equipment", c.Equipment)
+	if len(c.Magic) > 0 {
+		items := []string{}
+		for _, sp := range c.Magic {
+			items = append(items, fmt.Sprintf("**%s** (%s %d): %s", sp.Name, sp.Type, sp.Rank, sp.Description))
+		}
+		writeMarkdownList(b, "Spells", items)
+	}
+	fmt.Fprintf(b, "\n---\n\nSeed: `%s`\n", c.Seed)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeMarkdownList writes a titled Markdown list, skipping it if empty.
+func writeMarkdownList(w io.Writer, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n## %s\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(w, "- %s\n", item)
+	}
+}
@@ -0,0 +1,139 @@
+package sotdlgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+var testCharacter = Character{
+	Name:        "Borkenhekenaken",
+	Gender:      "Male",
+	Pronouns:    Pronouns{"he", "him", "his"},
+	Ancestry:    "Goblin",
+	NovicePath:  "Magician",
+	ExpertPath:  "Wizard",
+	LangAndProf: []string{"You speak the Common Tongue and Goblin."},
+	Talents:     []string{"Shadowsight: You see in areas obscured by shadows."},
+	Level:       3,
+	Attributes: Attributes{Strength: 8, Agility: 12, Intellect: 12, Will: 10,
+		Speed: 10, Power: 1, Health: 8, Size: "1/2", Defense: 12, Perception: 13},
+	Seed: "1575d911f49e59ee",
+}
+
+func TestRenderers(t *testing.T) {
+	for _, name := range []string{"json", "yaml", "markdown", "text"} {
+		b := &bytes.Buffer{}
+		if err := testCharacter.Render(b, name); err != nil {
+			t.Errorf("Failed to render %s: %v", name, err)
+		}
+		if !strings.Contains(b.String(), testCharacter.Name) {
+			t.Errorf("%s output is missing the character's name.", name)
+		}
+	}
+	if _, err := GetRenderer("parchment"); err == nil {
+		t.Error("Expected an error for an unknown format.")
+	}
+}
+
+func TestJSONRenderer(t *testing.T) {
+	b := &bytes.Buffer{}
+	testCharacter.Render(b, "json")
+	var c Character
+	if err := json.Unmarshal(b.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != testCharacter.Name || c.Attributes.Agility != 12 {
+		t.Error("JSON output does not round-trip.")
+	}
+}
+
+func TestTextRenderer(t *testing.T) {
+	os.Setenv("COLUMNS", "200")
+	defer os.Unsetenv("COLUMNS")
+	c := testCharacter
+	c.Talents = []string{"Wordy: " + strings.Repeat("This talent wraps. ", 12)}
+	r, _ := GetRenderer("text")
+	b, want, wide := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+	r.Render(b, c)
+	c.WriteText(want, defaultWidth)
+	c.WriteText(wide, 200)
+	if b.String() != want.String() || b.String() == wide.String() {
+		t.Error("Registered text renderer does not use the default width.")
+	}
+	b.Reset()
+	want.Reset()
+	TextRenderer{Width: minWidth}.Render(b, c)
+	c.WriteText(want, minWidth)
+	if b.String() != want.String() {
+		t.Error("Text renderer does not use its width.")
+	}
+}
+
+func TestYAMLRenderer(t *testing.T) {
+	b := &bytes.Buffer{}
+	testCharacter.Render(b, "yaml")
+	out := b.String()
+	for _, s := range []string{
+		`name: "Borkenhekenaken"`,
+		"attributes:\n  strength: 8\n",
+		"pronouns:\n  subject: \"he\"\n",
+		"talents:\n  - \"Shadowsight",
+	} {
+		if !strings.Contains(out, s) {
+			t.Errorf("YAML output is missing '%s'.", s)
+		}
+	}
+	if strings.Contains(out, "purpose") {
+		t.Error("YAML output includes an omitted empty field.")
+	}
+
+	// Dice are strings, as in the JSON encoding.
+	c := testCharacter
+	c.Weapons = []Weapon{{Name: "Club", Damage: Die{1, 2}}}
+	b.Reset()
+	c.Render(b, "yaml")
+	if !strings.Contains(b.String(), `    damage: "1d6+2"`) {
+		t.Errorf("YAML output is missing the weapon's damage:\n%s", b.String())
+	}
+}
+
+func TestRendererForAccept(t *testing.T) {
+	name, _, ok := RendererForAccept("text/html;q=0.9, text/markdown")
+	if !ok || name != "markdown" {
+		t.Errorf("Expected markdown renderer, got '%s'.", name)
+	}
+	name, _, _ = RendererForAccept("text/markdown;q=0.5, text/html;q=0.5, application/json;q=0.8")
+	if name != "json" {
+		t.Errorf("Expected json renderer, got '%s'.", name)
+	}
+	name, _, _ = RendererForAccept("text/html;q=0.5, text/markdown;q=0.5")
+	if name != "html" {
+		t.Errorf("Expected the first of equally preferred renderers, got '%s'.", name)
+	}
+	if _, _, ok = RendererForAccept("text/html;q=0"); ok {
+		t.Error("Expected no renderer for a refused type.")
+	}
+	if _, _, ok = RendererForAccept("image/png"); ok {
+		t.Error("Expected no renderer for image/png.")
+	}
+
+	// Wildcards match, after more specific ranges of the same q-value.
+	for accept, want := range map[string]string{
+		"*/*":                            "json",
+		"text/*":                         "text",
+		"application/pdf;q=0.1, */*":     "json",
+		"text/*, text/markdown":          "markdown",
+		"text/*;q=0.5, application/json": "json",
+		"image/png, text/*;q=0.2":        "text",
+	} {
+		if name, _, ok = RendererForAccept(accept); !ok || name != want {
+			t.Errorf("Accept '%s': expected %s renderer, got '%s'.", accept, want, name)
+		}
+	}
+	if _, _, ok = RendererForAccept("image/*"); ok {
+		t.Error("Expected no renderer for image/*.")
+	}
+}
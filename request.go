@@ -0,0 +1,107 @@
+// Generation requests, as accepted by the web service.
+
+package sotdlgen
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GenerateRequest holds the options for generating a character as a JSON
+// document. Fields left empty are randomized.
+type GenerateRequest struct {
+	Name        string   `json:"name,omitempty"`
+	Ethnicities []string `json:"ethnicities,omitempty"`
+	NameGen     string   `json:"name_gen,omitempty"`
+	Gender      string   `json:"gender,omitempty"`
+	Genders     []string `json:"genders,omitempty"`
+	Pronouns    string   `json:"pronouns,omitempty"`
+	Level       *int     `json:"level,omitempty"`
+	Ancestry    string   `json:"ancestry,omitempty"`
+	NovicePath  string   `json:"novice_path,omitempty"`
+	ExpertPath  string   `json:"expert_path,omitempty"`
+	MasterPath  string   `json:"master_path,omitempty"`
+	Seed        string   `json:"seed,omitempty"`
+	Constraints []string `json:"constraints,omitempty"`
+}
+
+// Number of hex digits in a seed.
+const seedLen = 16
+
+// Validate checks the request, reporting every invalid field.
+func (r GenerateRequest) Validate() error {
+	errs := ValidationError{}
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, FieldError{field, fmt.Sprintf(format, args...)})
+	}
+	if r.Level != nil && (*r.Level < 0 || *r.Level > maxLevel) {
+		add("level", "must be in [0..%d], got %d", maxLevel, *r.Level)
+	}
+	if r.Ancestry != "" && !stringIn(ancestries, r.Ancestry) {
+		add("ancestry", "unknown ancestry '%s'", r.Ancestry)
+	}
+	tiers := []struct {
+		field string
+		path  string
+		paths []string
+		level int
+	}{
+		{"novice_path", r.NovicePath, novicePaths, noviceLevel},
+		{"expert_path", r.ExpertPath, expertPaths, expertLevel},
+		{"master_path", r.MasterPath, masterPaths, masterLevel},
+	}
+	for _, t := range tiers {
+		switch {
+		case t.path == "":
+		case !stringIn(t.paths, t.path):
+			add(t.field, "unknown path '%s'", t.path)
+		case r.Level != nil && *r.Level < t.level:
+			add(t.field, "not available below level %d", t.level)
+		}
+	}
+	if r.NameGen != "" && r.NameGen != NameGenList && r.NameGen != NameGenMarkov {
+		add("name_gen", "must be one of {%s, %s}, got '%s'", NameGenList, NameGenMarkov, r.NameGen)
+	}
+	for _, g := range r.Genders {
+		if strings.TrimSpace(g) == "" || strings.Contains(g, ",") {
+			add("genders", "invalid gender '%s'", g)
+		}
+	}
+	if r.Seed != "" {
+		if _, err := hex.DecodeString(r.Seed); err != nil || len(r.Seed) != seedLen {
+			add("seed", "must be %d hex digits, got '%s'", seedLen, r.Seed)
+		}
+	}
+	if _, err := ParseConstraints(r.Constraints); err != nil {
+		errs = append(errs, err.(ValidationError)...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Opts converts the request to generation options.
+func (r GenerateRequest) Opts() Opts {
+	opts := Opts{
+		Name:        r.Name,
+		Ethnicity:   strings.Join(r.Ethnicities, ","),
+		NameGen:     r.NameGen,
+		Gender:      r.Gender,
+		Genders:     strings.Join(r.Genders, ","),
+		Pronouns:    r.Pronouns,
+		Ancestry:    r.Ancestry,
+		NovicePath:  r.NovicePath,
+		ExpertPath:  r.ExpertPath,
+		MasterPath:  r.MasterPath,
+		Seed:        r.Seed,
+		Constraints: r.Constraints,
+		LogLevel:    "ERROR",
+	}
+	if r.Level != nil {
+		opts.Level = strconv.Itoa(*r.Level)
+	}
+	return opts
+}
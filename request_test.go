@@ -0,0 +1,62 @@
+package sotdlgen
+
+import (
+	"testing"
+)
+
+func TestGenerateRequestValidate(t *testing.T) {
+	level := 2
+	req := GenerateRequest{
+		Level:       &level,
+		Ancestry:    "Orc",
+		NovicePath:  "Warrior",
+		Genders:     []string{"Female", "Male"},
+		Seed:        "00112233aabbccdd",
+		Constraints: []string{"strength >= 11"},
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Unexpected error: %v.", err)
+	}
+	level = 20
+	req = GenerateRequest{
+		Level:       &level,
+		Ancestry:    "Gnoll",
+		ExpertPath:  "Warrior",
+		NameGen:     "random",
+		Seed:        "00ff",
+		Constraints: []string{"height in tall"},
+	}
+	err := req.Validate()
+	verr, ok := err.(ValidationError)
+	if !ok {
+		t.Fatalf("Expected a ValidationError, got %v.", err)
+	}
+	want := []string{"level", "ancestry", "expert_path", "name_gen", "seed", "constraints"}
+	if len(verr) != len(want) {
+		t.Fatalf("Expected %d invalid fields, got %v.", len(want), verr)
+	}
+	for i, fe := range verr {
+		if fe.Field != want[i] {
+			t.Errorf("Expected field '%s', got '%s'.", want[i], fe.Field)
+		}
+	}
+}
+
+func TestGenerateRequestOpts(t *testing.T) {
+	level := 3
+	opts := GenerateRequest{
+		Level:       &level,
+		Ethnicities: []string{"Dwarf", "Human"},
+		Genders:     []string{"Female", "Nonbinary"},
+		Constraints: []string{"not spellcaster"},
+	}.Opts()
+	if opts.Level != "3" || opts.Ethnicity != "Dwarf,Human" || opts.Genders != "Female,Nonbinary" {
+		t.Errorf("Options not converted: %+v.", opts)
+	}
+	if len(opts.Constraints) != 1 {
+		t.Error("Constraints not passed.")
+	}
+	if (GenerateRequest{}).Opts().Level != "" {
+		t.Error("Expected an unset level to be randomized.")
+	}
+}
@@ -0,0 +1,85 @@
+// Listings of the rules data available for generation.
+
+package sotdlgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PathTiers names the path tiers in order.
+var PathTiers = []string{"novice", "expert", "master"}
+
+// Ancestries lists the ancestries.
+func Ancestries() []string {
+	return append([]string{}, ancestries...)
+}
+
+// Genders lists the default genders.
+func Genders() []string {
+	return append([]string{}, genders...)
+}
+
+// PathsByTier lists the paths of the given tier, or all paths, in tier
+// order, if the tier is empty.
+func PathsByTier(tier string) ([]string, error) {
+	switch strings.ToLower(tier) {
+	case "":
+		paths := append([]string{}, novicePaths...)
+		paths = append(paths, expertPaths...)
+		return append(paths, masterPaths...), nil
+	case "novice":
+		return append([]string{}, novicePaths...), nil
+	case "expert":
+		return append([]string{}, expertPaths...), nil
+	case "master":
+		return append([]string{}, masterPaths...), nil
+	}
+	return nil, fmt.Errorf("unknown tier '%s'; expected one of {%s}", tier, strings.Join(PathTiers, ", "))
+}
+
+// PathTier returns the tier of the path ("ancestry" for ancestries), or ""
+// if there is no such path.
+func PathTier(path string) string {
+	switch {
+	case stringIn(ancestries, path):
+		return "ancestry"
+	case stringIn(novicePaths, path):
+		return "novice"
+	case stringIn(expertPaths, path):
+		return "expert"
+	case stringIn(masterPaths, path):
+		return "master"
+	}
+	return ""
+}
+
+// LookupPath returns the canonical name of the ancestry or path, ignoring
+// case, and whether it exists.
+func LookupPath(name string) (string, bool) {
+	all, _ := PathsByTier("")
+	return canonical(append(Ancestries(), all...), name)
+}
+
+// NameLists returns the db's name lists for the ancestry, or all of them if
+// the ancestry is empty.
+func (db *CharDB) NameLists(ancestry string) []NameList {
+	lists := []NameList{}
+	for _, nl := range db.Names {
+		if ancestry == "" || strings.EqualFold(nl.Ancestry, ancestry) {
+			lists = append(lists, nl)
+		}
+	}
+	sort.SliceStable(lists, func(i, j int) bool {
+		a, b := lists[i], lists[j]
+		if a.Ancestry != b.Ancestry {
+			return a.Ancestry < b.Ancestry
+		}
+		if a.Ethnicity != b.Ethnicity {
+			return a.Ethnicity < b.Ethnicity
+		}
+		return a.Type < b.Type
+	})
+	return lists
+}
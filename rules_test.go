@@ -0,0 +1,36 @@
+package sotdlgen
+
+import "testing"
+
+func TestPathsByTier(t *testing.T) {
+	all, err := PathsByTier("")
+	if err != nil || len(all) != len(novicePaths)+len(expertPaths)+len(masterPaths) {
+		t.Errorf("Incorrect path listing: %d paths, %v.", len(all), err)
+	}
+	expert, _ := PathsByTier("Expert")
+	if len(expert) != len(expertPaths) || PathTier(expert[0]) != "expert" {
+		t.Errorf("Incorrect expert paths: %v.", expert)
+	}
+	if _, err = PathsByTier("legendary"); err == nil {
+		t.Error("Expected an error for an unknown tier.")
+	}
+	if p, ok := LookupPath("mage knight"); !ok || p != "Mage Knight" {
+		t.Errorf("Expected 'Mage Knight', got '%s'.", p)
+	}
+}
+
+func TestNameLists(t *testing.T) {
+	useTestDB(t)
+	lists := db.NameLists("goblin")
+	if len(lists) == 0 {
+		t.Fatal("No goblin name lists.")
+	}
+	for _, nl := range lists {
+		if nl.Ancestry != "Goblin" {
+			t.Errorf("Unexpected %s name list.", nl.Ancestry)
+		}
+	}
+	if len(db.NameLists("")) != len(db.Names) {
+		t.Error("Expected all name lists.")
+	}
+}
@@ -0,0 +1,181 @@
+// Plain-text character sheet.
+
+package sotdlgen
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// Bounds on the width of the text sheet.
+const (
+	defaultWidth = 80
+	minWidth     = 40
+)
+
+// ttyWidth is the width of the terminal on stdout, or 0 if stdout is not a
+// terminal. It is queried once per process.
+var (
+	ttyWidth     int
+	ttyWidthOnce sync.Once
+)
+
+// TermWidth returns the width of the terminal on stdout as reported by
+// stty, falling back to $COLUMNS, e.g., when stdout is piped.
+func TermWidth() int {
+	ttyWidthOnce.Do(func() {
+		// stty reports the size of the terminal on its stdin as "rows cols".
+		cmd := exec.Command("stty", "size")
+		cmd.Stdin = os.Stdout
+		if out, err := cmd.Output(); err == nil {
+			if f := strings.Fields(string(out)); len(f) == 2 {
+				ttyWidth, _ = strconv.Atoi(f[1])
+			}
+		}
+	})
+	w := ttyWidth
+	if w <= 0 {
+		var err error
+		if w, err = strconv.Atoi(os.Getenv("COLUMNS")); err != nil || w <= 0 {
+			return defaultWidth
+		}
+	}
+	if w < minWidth {
+		return minWidth
+	}
+	return w
+}
+
+// modifier returns the modifier for an attribute score.
+func modifier(score int) int {
+	return score - 10
+}
+
+// signed formats an integer with an explicit sign.
+func signed(n int) string {
+	return fmt.Sprintf("%+d", n)
+}
+
+// wrapText breaks text starting at column indent into lines of at most width
+// characters, indenting each line after the first by indent spaces.
+func wrapText(text string, width, indent int) string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return ""
+	}
+	pad := strings.Repeat(" ", indent)
+	var b strings.Builder
+	lineLen := indent
+	for i, w := range words {
+		if i > 0 {
+			if lineLen+1+len(w) > width {
+				b.WriteString("\n" + pad)
+				lineLen = indent
+			} else {
+				b.WriteString(" ")
+				lineLen++
+			}
+		}
+		b.WriteString(w)
+		lineLen += len(w)
+	}
+	return b.String()
+}
+
+// Paths returns the character's novice, expert and master paths, omitting
+// any it has not entered.
+func (c Character) Paths() []string {
+	paths := []string{}
+	for _, p := range []string{c.NovicePath, c.ExpertPath, c.MasterPath} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// WriteText writes a plain-text character sheet, wrapping long text to the
+// given width.
+func (c Character) WriteText(w io.Writer, width int) error {
+	if width < minWidth {
+		width = minWidth
+	}
+	a := c.Attributes
+	b := &strings.Builder{}
+
+	fmt.Fprintln(b, c.Name)
+	fmt.Fprintln(b, strings.Repeat("=", len([]rune(c.Name))))
+	fmt.Fprintf(b, "Level %d %s %s (%s)\n", c.Level, c.Gender, c.Ancestry, c.Pronouns)
+	if paths := c.Paths(); len(paths) > 0 {
+		fmt.Fprintf(b, "Paths: %s\n", strings.Join(paths, " / "))
+	}
+	if c.ApparentAncestry != "" {
+		fmt.Fprintf(b, "Guise: %s, a %s %s\n", c.ApparentName, c.ApparentGender, c.ApparentAncestry)
+	}
+	if c.Purpose != "" {
+		fmt.Fprintf(b, "Purpose: %s; key in %s\n", c.Purpose, strings.ToLower(c.KeyLocation))
+	}
+
+	fmt.Fprintln(b, "\nAttributes")
+	tw := tabwriter.NewWriter(b, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  Strength\t%d (%s)\tAgility\t%d (%s)\n",
+		a.Strength, signed(modifier(a.Strength)), a.Agility, signed(modifier(a.Agility)))
+	fmt.Fprintf(tw, "  Intellect\t%d (%s)\tWill\t%d (%s)\n",
+		a.Intellect, signed(modifier(a.Intellect)), a.Will, signed(modifier(a.Will)))
+	tw.Flush()
+
+	fmt.Fprintln(b, "\nCharacteristics")
+	tw = tabwriter.NewWriter(b, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  Health\t%d\tHealing Rate\t%d\tSize\t%s\n", a.Health, a.HealingRate, a.Size)
+	fmt.Fprintf(tw, "  Defense\t%d\tPerception\t%d (%s)\tSpeed\t%d\n",
+		a.Defense, a.Perception, signed(modifier(a.Perception)), a.Speed)
+	fmt.Fprintf(tw, "  Power\t%d\tInsanity\t%d\tCorruption\t%d\n", a.Power, a.Insanity, a.Corruption)
+	tw.Flush()
+
+	writeList(b, "Languages and Professions", c.LangAndProf, width)
+	writeList(b, "Talents", c.Talents, width)
+
+	if len(c.Weapons) > 0 {
+		items := []string{}
+		for _, wp := range c.Weapons {
+			items = append(items, fmt.Sprintf("%s (%s, %s damage)", wp.Name, wp.Hands, wp.Damage.toStr()))
+		}
+		writeList(b, "Weapons", items, width)
+	}
+	if len(c.Armor) > 0 {
+		items := []string{}
+		for _, ar := range c.Armor {
+			items = append(items, fmt.Sprintf("%s (Defense %d)", ar.Name, ar.DefenseBonus))
+		}
+		writeList(b, "Armor", items, width)
+	}
+	writeList(b, "Equipment", c.Equipment, width)
+	if len(c.Magic) > 0 {
+		items := []string{}
+		for _, sp := range c.Magic {
+			items = append(items, fmt.Sprintf("%s (%s %d): %s", sp.Name, sp.Type, sp.Rank, sp.Description))
+		}
+		writeList(b, "Spells", items, width)
+	}
+
+	fmt.Fprintf(b, "\nSeed: %s\n", c.Seed)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeList writes a titled, bulleted list, skipping it if empty.
+func writeList(w io.Writer, title string, items []string, width int) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s\n", title)
+	for _, item := range items {
+		fmt.Fprintf(w, "  - %s\n", wrapText(item, width, 4))
+	}
+}
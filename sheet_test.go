@@ -0,0 +1,53 @@
+package sotdlgen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWrapText(t *testing.T) {
+	text := "You can use an action to make an attack roll with a weapon you wield against a target."
+	lines := strings.Split(wrapText(text, 30, 4), "\n")
+	if len(lines) < 2 {
+		t.Fatal("Text was not wrapped.")
+	}
+	for i, line := range lines {
+		if i == 0 {
+			line = "    " + line
+		}
+		if len(line) > 30 {
+			t.Errorf("Line too long: '%s'.", line)
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	c := Character{
+		Name:       "Borkenhekenaken",
+		Gender:     "Male",
+		Pronouns:   Pronouns{"he", "him", "his"},
+		Ancestry:   "Goblin",
+		NovicePath: "Magician",
+		Level:      1,
+		Talents:    []string{strings.Repeat("Shadowsight ", 20)},
+		Attributes: Attributes{Strength: 8, Agility: 12, Intellect: 11, Will: 10},
+		Seed:       "1575d911f49e59ee",
+	}
+	b := &bytes.Buffer{}
+	if err := c.WriteText(b, 60); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, s := range []string{"Borkenhekenaken", "Level 1 Male Goblin (he/him)",
+		"Paths: Magician", "Strength", "12 (+2)", "Talents", "1575d911f49e59ee"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("Sheet is missing '%s'.", s)
+		}
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) > 60 {
+			t.Errorf("Line exceeds width: '%s'.", line)
+		}
+	}
+}
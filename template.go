@@ -0,0 +1,125 @@
+// User-supplied and bundled character sheet templates.
+
+package sotdlgen
+
+import (
+	"embed"
+	htmltemplate "html/template"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed assets/templates
+var bundledTemplates embed.FS
+
+const bundledTemplateDir = "assets/templates/"
+
+// TemplateFuncs are the helper functions available to sheet templates.
+var TemplateFuncs = map[string]interface{}{
+	"mod":     modifier,
+	"signed":  signed,
+	"dice":    dice,
+	"wrap":    wrapText,
+	"summary": summary,
+	"join":    strings.Join,
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+}
+
+// dice formats n d6 plus optional pips, e.g., "2d6+1".
+func dice(n int, pips ...int) string {
+	d := Die{code: n}
+	if len(pips) > 0 {
+		d.pips = pips[0]
+	}
+	return d.toStr()
+}
+
+// summary shortens a talent or other description to its title, i.e., the
+// text before the first colon or period, or else its first few words.
+func summary(text string) string {
+	const maxLen = 48
+	if i := strings.IndexAny(text, ":."); i > 0 && i <= maxLen {
+		return text[:i]
+	}
+	words := strings.Fields(text)
+	if len(words) > 6 {
+		return strings.Join(words[:6], " ") + "..."
+	}
+	return text
+}
+
+// String formats the die, e.g., "2d6+1".
+func (d Die) String() string {
+	return d.toStr()
+}
+
+// BundledTemplates lists the names of the templates shipped with sotdlgen.
+func BundledTemplates() []string {
+	names := []string{}
+	entries, _ := bundledTemplates.ReadDir(strings.TrimSuffix(bundledTemplateDir, "/"))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateRenderer renders a character with a parsed template.
+type templateRenderer struct {
+	execute     func(w io.Writer, data interface{}) error
+	contentType string
+}
+
+func (r templateRenderer) ContentType() string { return r.contentType }
+
+func (r templateRenderer) Render(w io.Writer, c Character) error {
+	return r.execute(w, c)
+}
+
+// NewTemplateRenderer parses a character sheet template, either one of the
+// bundled templates or a file. Templates whose names end in .html or .htm
+// are parsed with html/template; all others with text/template.
+func NewTemplateRenderer(name string) (Renderer, error) {
+	var (
+		src []byte
+		err error
+	)
+	if stringIn(BundledTemplates(), name) {
+		src, err = bundledTemplates.ReadFile(bundledTemplateDir + name)
+	} else {
+		src, err = ioutil.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseTemplate(filepath.Base(name), string(src))
+}
+
+// ParseTemplate parses template source as a character sheet renderer, using
+// html/template if the name ends in .html or .htm.
+func ParseTemplate(name, src string) (Renderer, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".html", ".htm":
+		t, err := htmltemplate.New(name).Funcs(TemplateFuncs).Parse(src)
+		if err != nil {
+			return nil, err
+		}
+		return templateRenderer{t.Execute, "text/html; charset=utf-8"}, nil
+	case ".md":
+		t, err := template.New(name).Funcs(TemplateFuncs).Parse(src)
+		if err != nil {
+			return nil, err
+		}
+		return templateRenderer{t.Execute, "text/markdown; charset=utf-8"}, nil
+	}
+	t, err := template.New(name).Funcs(TemplateFuncs).Parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return templateRenderer{t.Execute, "text/plain; charset=utf-8"}, nil
+}
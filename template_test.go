@@ -0,0 +1,53 @@
+package sotdlgen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBundledTemplates(t *testing.T) {
+	names := BundledTemplates()
+	if len(names) == 0 {
+		t.Fatal("No bundled templates found.")
+	}
+	c := testCharacter
+	c.Weapons = []Weapon{{Name: "Dagger", Hands: "Off", Damage: Die{1, 1}}}
+	for _, name := range names {
+		r, err := NewTemplateRenderer(name)
+		if err != nil {
+			t.Errorf("Failed to parse %s: %v", name, err)
+			continue
+		}
+		b := &bytes.Buffer{}
+		if err = r.Render(b, c); err != nil {
+			t.Errorf("Failed to render %s: %v", name, err)
+		}
+		if !strings.Contains(strings.ToLower(b.String()), strings.ToLower(c.Name)) {
+			t.Errorf("%s output is missing the character's name.", name)
+		}
+	}
+}
+
+func TestParseTemplate(t *testing.T) {
+	r, err := ParseTemplate("t.txt", `{{mod .Attributes.Agility | signed}} {{dice 2 1}} {{join .Paths "/"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &bytes.Buffer{}
+	r.Render(b, testCharacter)
+	if b.String() != "+2 2d6+1 Magician/Wizard" {
+		t.Errorf("Incorrect template output '%s'.", b.String())
+	}
+	c := testCharacter
+	c.Name = "<script>"
+	r, _ = ParseTemplate("t.html", `<p>{{.Name}}</p>`)
+	b.Reset()
+	r.Render(b, c)
+	if strings.Contains(b.String(), "<script>") {
+		t.Error("HTML template did not escape its input.")
+	}
+	if r.ContentType() != "text/html; charset=utf-8" {
+		t.Errorf("Incorrect content type '%s'.", r.ContentType())
+	}
+}
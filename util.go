@@ -5,6 +5,7 @@ package sotdlgen
 import (
 	"encoding/binary"
 	"encoding/hex"
+	"fmt"
 	"hash/fnv"
 	"io/ioutil"
 	"math/rand"
@@ -16,6 +17,10 @@ import (
 
 var dataDir = setDataDir()
 
+// Random source for character generation; reseeded from each character's
+// seed so that generation is reproducible.
+var rng = rand.New(rand.NewSource(time.Now().UTC().UnixNano()))
+
 func setDataDir() string {
 	dir := os.Getenv("GOPATH") + "/src/github.com/gruevyhat/sotdlgen/assets/"
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
@@ -38,6 +43,35 @@ func arrayContains(arr []string, s string) bool {
 	return false
 }
 
+func stringIn(arr []string, s string) bool {
+	for _, a := range arr {
+		if a == s {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma-delimited list, trimming whitespace and dropping
+// empty items.
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// capitalize upper-cases the first letter of s.
+func capitalize(s string) string {
+	for i, r := range s {
+		return strings.ToUpper(string(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
+
 func arrayRemove(s string, a []string) []string {
 	for i, x := range a {
 		if x == "" || x == s {
@@ -63,14 +97,14 @@ func setSeed(charHash string) (string, error) {
 		return charHash, err
 	}
 	seed := binary.BigEndian.Uint64(h)
-	rand.Seed(int64(seed))
+	rng.Seed(int64(seed))
 	log.Info("Set new seed:", seed)
 	return charHash, nil
 }
 
 func sampleWithoutReplacement(choices []string, n int) []string {
 	samples := []string{}
-	idxs := rand.Perm(len(choices))
+	idxs := rng.Perm(len(choices))
 	for i := 0; i < n; i++ {
 		samples = append(samples, choices[idxs[i]])
 	}
@@ -78,13 +112,13 @@ func sampleWithoutReplacement(choices []string, n int) []string {
 }
 
 func randomChoice(choices []string) string {
-	r := rand.Intn(len(choices))
+	r := rng.Intn(len(choices))
 	return choices[r]
 }
 
 func randomInt(min, max int) int {
 	// Returns an int in [min,max).
-	return rand.Intn(max-min) + min
+	return rng.Intn(max-min) + min
 }
 
 func weightedRandomChoice(choices []string, weights []float64) string {
@@ -92,7 +126,7 @@ func weightedRandomChoice(choices []string, weights []float64) string {
 	for _, w := range weights {
 		sum += w
 	}
-	r := rand.Float64()*sum - 1.0
+	r := rng.Float64()*sum - 1.0
 	total := 0.0
 	for i, w := range weights {
 		total += w
@@ -118,3 +152,33 @@ func (d Die) toStr() string {
 	}
 	return dieStr
 }
+
+// MarshalText encodes the die in the form "1d6+1".
+func (d Die) MarshalText() ([]byte, error) {
+	return []byte(d.toStr()), nil
+}
+
+// UnmarshalText decodes a die of the form "1d6+1" or "1d6".
+func (d *Die) UnmarshalText(text []byte) error {
+	s := strings.ToLower(strings.TrimSpace(string(text)))
+	if s == "" {
+		*d = Die{}
+		return nil
+	}
+	parts := strings.SplitN(s, "d6", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid die '%s'", text)
+	}
+	code, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return fmt.Errorf("invalid die '%s'", text)
+	}
+	pips := 0
+	if parts[1] != "" {
+		if pips, err = strconv.Atoi(strings.TrimPrefix(parts[1], "+")); err != nil {
+			return fmt.Errorf("invalid die '%s'", text)
+		}
+	}
+	*d = Die{code, pips}
+	return nil
+}
@@ -0,0 +1,86 @@
+// Step-by-step interactive character creation.
+
+package sotdlgen
+
+import "strconv"
+
+// Prompter asks the user to make the choices of BuildCharacter.
+type Prompter interface {
+	// Choose asks for one of the options, returning "" for a random pick.
+	Choose(step string, options []string) (string, error)
+	// Ask asks for free text, returning "" for a random or default value.
+	Ask(step string) (string, error)
+	// Show presents the character as it stands after a step.
+	Show(c Character)
+}
+
+// Attributes that may be increased.
+var increasableAttributes = []string{"Strength", "Agility", "Intellect", "Will"}
+
+// BuildCharacter creates a character step by step, asking the prompter for
+// the ancestry, gender, name, level, each path tier, attribute increases and
+// professions in turn. Options that are set are used without asking.
+func BuildCharacter(opts Opts, p Prompter) (c Character, err error) {
+	if err = LoadCharDB(opts.DataFile); err != nil {
+		return c, err
+	}
+	c.setCharSeed(opts.Seed)
+
+	// ask and choose record the first error, after which they return "" so
+	// the remaining steps complete at random.
+	ask := func(step, value string) string {
+		if value == "" && err == nil {
+			value, err = p.Ask(step)
+		}
+		return value
+	}
+	choose := func(step, value string, options []string) string {
+		if value == "" && err == nil {
+			value, err = p.Choose(step, options)
+		}
+		return value
+	}
+	levels := []string{}
+	for i := 0; i <= maxLevel; i++ {
+		levels = append(levels, strconv.Itoa(i))
+	}
+	c.chooseAttr = func() string {
+		return choose("Attribute to increase", "", increasableAttributes)
+	}
+
+	c.setPath(choose("Ancestry", opts.Ancestry, ancestries))
+	p.Show(c)
+	c.setGender(choose("Gender", opts.Gender, genderChoices(opts.Genders)), opts.Genders)
+	c.setPronouns(ask("Pronouns", opts.Pronouns))
+	c.setName(ask("Name", opts.Name), opts.Ethnicity, opts.NameGen, opts.UsedNames)
+	c.applyAncestryRules(opts)
+	p.Show(c)
+	level := parseLevel(choose("Level", opts.Level, levels))
+	tiers := map[int]struct {
+		step  string
+		path  string
+		paths func() []string
+	}{
+		noviceLevel: {"Novice path", opts.NovicePath, func() []string { return novicePaths }},
+		expertLevel: {"Expert path", opts.ExpertPath, func() []string { return expertPaths }},
+		masterLevel: {"Master path", opts.MasterPath, c.eligibleMasterPaths},
+	}
+	// Each path is shown once chosen, i.e., before the next is asked for.
+	c.advance(level, func(tier int) string {
+		if tier > noviceLevel {
+			p.Show(c)
+		}
+		t := tiers[tier]
+		return choose(t.step, t.path, t.paths())
+	})
+	if c.Level >= noviceLevel {
+		p.Show(c)
+	}
+	c.setProfessions(ask("Professions", opts.Professions))
+	c.chooseAttr = nil
+	if err != nil {
+		return c, err
+	}
+	p.Show(c)
+	return c, nil
+}
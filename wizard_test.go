@@ -0,0 +1,73 @@
+package sotdlgen
+
+import (
+	"errors"
+	"testing"
+)
+
+// scriptedPrompter answers each step from a script, and "" when it has no
+// answer.
+type scriptedPrompter struct {
+	answers map[string][]string
+	steps   []string
+	shown   int
+}
+
+func (p *scriptedPrompter) answer(step string) (string, error) {
+	p.steps = append(p.steps, step)
+	as := p.answers[step]
+	if len(as) == 0 {
+		return "", nil
+	}
+	if as[0] == "EOF" {
+		return "", errors.New("EOF")
+	}
+	p.answers[step] = as[1:]
+	return as[0], nil
+}
+
+func (p *scriptedPrompter) Choose(step string, options []string) (string, error) {
+	return p.answer(step)
+}
+
+func (p *scriptedPrompter) Ask(step string) (string, error) { return p.answer(step) }
+
+func (p *scriptedPrompter) Show(c Character) { p.shown++ }
+
+func TestBuildCharacter(t *testing.T) {
+	useTestDB(t)
+	p := &scriptedPrompter{answers: map[string][]string{
+		"Ancestry":              {"Orc"},
+		"Gender":                {"Female"},
+		"Name":                  {"Grisha"},
+		"Level":                 {"3"},
+		"Novice path":           {"Warrior"},
+		"Attribute to increase": {"Strength", "Strength", "Will", "Will"},
+		"Professions":           {"Blacksmith, Soldier"},
+	}}
+	c, err := BuildCharacter(Opts{Seed: "1575d911f49e59ee", LogLevel: "ERROR"}, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Ancestry != "Orc" || c.Gender != "Female" || c.Name != "Grisha" || c.Level != 3 ||
+		c.NovicePath != "Warrior" || c.ExpertPath == "" {
+		t.Errorf("Choices not applied: %+v.", c)
+	}
+	if len(p.answers["Attribute to increase"]) != 0 {
+		t.Errorf("Expected four attribute increases; %d unused.", len(p.answers["Attribute to increase"]))
+	}
+	if !stringIn(c.LangAndProf, "Professions: Blacksmith, Soldier.") || stringIn(c.LangAndProf, professionsPlaceholder) {
+		t.Errorf("Professions not set: %v.", c.LangAndProf)
+	}
+	if p.shown < 4 {
+		t.Errorf("Expected the character to be shown after each step, got %d.", p.shown)
+	}
+	if stringIn(p.steps, "Master path") {
+		t.Error("Asked for a master path below level 7.")
+	}
+
+	p = &scriptedPrompter{answers: map[string][]string{"Level": {"EOF"}}}
+	if _, err = BuildCharacter(Opts{LogLevel: "ERROR"}, p); err == nil {
+		t.Error("Expected the prompter's error.")
+	}
+}
@@ -0,0 +1,170 @@
+// Minimal YAML encoding of the character model.
+
+package sotdlgen
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// writeYAML writes v as a YAML document, using the same field names and
+// omitempty rules as its JSON encoding.
+func writeYAML(w io.Writer, v interface{}) error {
+	b := &strings.Builder{}
+	b.WriteString("---\n")
+	encodeYAML(b, reflect.ValueOf(v), 0)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// yamlField is a struct field or map entry to be encoded.
+type yamlField struct {
+	key string
+	val reflect.Value
+}
+
+func encodeYAML(b *strings.Builder, v reflect.Value, indent int) {
+	pad := strings.Repeat("  ", indent)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			b.WriteString("null\n")
+			return
+		}
+		v = v.Elem()
+	}
+	// Values that encode themselves as text, e.g., dice, are strings in JSON.
+	if text, ok := yamlText(v); ok {
+		b.WriteString(strconv.Quote(text) + "\n")
+		return
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map:
+		fields := yamlFields(v)
+		if len(fields) == 0 {
+			b.WriteString("{}\n")
+			return
+		}
+		for _, f := range fields {
+			b.WriteString(pad + f.key + ":")
+			if isYAMLScalar(f.val) || yamlEmpty(f.val) {
+				b.WriteString(" ")
+				encodeYAML(b, f.val, indent+1)
+			} else {
+				b.WriteString("\n")
+				encodeYAML(b, f.val, indent+1)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			b.WriteString("[]\n")
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			e := v.Index(i)
+			if isYAMLScalar(e) || yamlEmpty(e) {
+				b.WriteString(pad + "- ")
+				encodeYAML(b, e, indent+1)
+				continue
+			}
+			// Nest the element's mapping under the dash.
+			sub := &strings.Builder{}
+			encodeYAML(sub, e, indent+1)
+			b.WriteString(pad + "- " + strings.TrimPrefix(sub.String(), pad+"  "))
+		}
+	case reflect.String:
+		b.WriteString(strconv.Quote(v.String()) + "\n")
+	case reflect.Bool:
+		b.WriteString(strconv.FormatBool(v.Bool()) + "\n")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10) + "\n")
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10) + "\n")
+	case reflect.Float32, reflect.Float64:
+		b.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64) + "\n")
+	default:
+		b.WriteString(strconv.Quote(fmt.Sprint(v.Interface())) + "\n")
+	}
+}
+
+// yamlFields lists the exported fields of a struct, named and filtered by
+// their json tags, or the entries of a map sorted by key.
+func yamlFields(v reflect.Value) []yamlField {
+	fields := []yamlField{}
+	if v.Kind() == reflect.Map {
+		for _, k := range v.MapKeys() {
+			fields = append(fields, yamlField{fmt.Sprint(k.Interface()), v.MapIndex(k)})
+		}
+		sort.Slice(fields, func(i, j int) bool { return fields[i].key < fields[j].key })
+		return fields
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := sf.Name
+		omitEmpty := false
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			parts := strings.Split(tag, ",")
+			if parts[0] == "-" {
+				continue
+			}
+			if parts[0] != "" {
+				name = parts[0]
+			}
+			omitEmpty = stringIn(parts[1:], "omitempty")
+		}
+		fv := v.Field(i)
+		if omitEmpty && fv.Kind() != reflect.Struct && yamlEmpty(fv) {
+			continue
+		}
+		if strings.ContainsAny(name, ":+ #") {
+			name = strconv.Quote(name)
+		}
+		fields = append(fields, yamlField{name, fv})
+	}
+	return fields
+}
+
+// yamlText returns the text of a value implementing encoding.TextMarshaler.
+func yamlText(v reflect.Value) (string, bool) {
+	if !v.CanInterface() {
+		return "", false
+	}
+	m, ok := v.Interface().(encoding.TextMarshaler)
+	if !ok {
+		return "", false
+	}
+	text, err := m.MarshalText()
+	return string(text), err == nil
+}
+
+func isYAMLScalar(v reflect.Value) bool {
+	if _, ok := yamlText(v); ok {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Interface:
+		return false
+	}
+	return true
+}
+
+// yamlEmpty reports whether v is empty in the sense of json's omitempty.
+func yamlEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Struct:
+		return len(yamlFields(v)) == 0
+	}
+	return v.IsZero()
+}